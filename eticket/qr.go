@@ -0,0 +1,19 @@
+package eticket
+
+import "rsc.io/qr"
+
+// QR кодирует токен в QR-код. Уровень коррекции Q, чтобы код читался с
+// потёртой распечатки или бликующего экрана телефона.
+func QR(token string) (*qr.Code, error) {
+	return qr.Encode(token, qr.Q)
+}
+
+// QRPNG рисует токен как QR-код в PNG.
+func QRPNG(token string) ([]byte, error) {
+	code, err := QR(token)
+	if err != nil {
+		return nil, err
+	}
+	code.Scale = 8
+	return code.PNG(), nil
+}
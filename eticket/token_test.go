@@ -0,0 +1,47 @@
+package eticket
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSignParseRoundTrip(t *testing.T) {
+	s := NewSigner([]byte("ticket-key"))
+	want := Claims{TicketID: 42, SessionID: 7}
+	got, err := s.Parse(s.Sign(want))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got != want {
+		t.Errorf("Parse = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseRejectsTampering(t *testing.T) {
+	s := NewSigner([]byte("ticket-key"))
+	token := s.Sign(Claims{TicketID: 42, SessionID: 7})
+	parts := strings.Split(token, ".")
+	other := NewSigner([]byte("another-key")).Sign(Claims{TicketID: 42, SessionID: 7})
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"other ticket id", "43." + parts[1] + "." + parts[2]},
+		{"other session id", parts[0] + ".8." + parts[2]},
+		{"other key", other},
+		{"signature cut", parts[0] + "." + parts[1] + "." + parts[2][:len(parts[2])-2]},
+		{"signature not base64", parts[0] + "." + parts[1] + ".!!!"},
+		{"no signature", parts[0] + "." + parts[1]},
+		{"extra part", token + ".x"},
+		{"empty", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Parse(tt.token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Parse(%q) error = %v, want ErrInvalidToken", tt.token, err)
+			}
+		})
+	}
+}
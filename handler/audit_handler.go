@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"cinema-system/model"
+	"cinema-system/repository"
+	"cinema-system/service"
+	"net/http"
+)
+
+// AuditHandler обрабатывает GET /api/audit?action=&actorId=&entity=&entityId=&limit=
+// (только admin).
+type AuditHandler struct {
+	svc *service.AuditService
+}
+
+func NewAuditHandler(svc *service.AuditService) *AuditHandler {
+	return &AuditHandler{svc: svc}
+}
+
+func (h *AuditHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	f := repository.AuditFilter{
+		Action: r.URL.Query().Get("action"),
+		Entity: r.URL.Query().Get("entity"),
+	}
+	var err error
+	if f.ActorID, err = queryInt(r, "actorId"); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid actorId")
+		return
+	}
+	if f.EntityID, err = queryInt(r, "entityId"); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid entityId")
+		return
+	}
+	if f.Limit, err = queryInt(r, "limit"); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid limit")
+		return
+	}
+	events, err := h.svc.Find(r.Context(), f)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if events == nil {
+		events = []*model.AuditEvent{}
+	}
+	writeJSON(w, http.StatusOK, events)
+}
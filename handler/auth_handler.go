@@ -1,24 +1,35 @@
 package handler
 
 import (
+	"cinema-system/middleware"
 	"cinema-system/model"
 	"cinema-system/service"
 	"encoding/json"
+	"errors"
+	"log"
+	"math"
 	"net/http"
-	"time"
-
-	"github.com/golang-jwt/jwt/v5"
+	"strconv"
+	"strings"
 )
 
-// AuthHandler обрабатывает регистрацию и логин.
+// AuthHandler обрабатывает регистрацию, логин, обновление токенов и выход.
 type AuthHandler struct {
 	svc       *service.UserService
+	tokens    *service.TokenService
+	accounts  *service.AccountService
+	guard     *service.LoginGuard
+	proxies   TrustedProxies
 	jwtSecret []byte
 }
 
-func NewAuthHandler(svc *service.UserService, jwtSecret string) *AuthHandler {
+func NewAuthHandler(svc *service.UserService, tokens *service.TokenService, accounts *service.AccountService, guard *service.LoginGuard, proxies TrustedProxies, jwtSecret string) *AuthHandler {
 	return &AuthHandler{
 		svc:       svc,
+		tokens:    tokens,
+		accounts:  accounts,
+		guard:     guard,
+		proxies:   proxies,
 		jwtSecret: []byte(jwtSecret),
 	}
 }
@@ -30,8 +41,42 @@ type authRequest struct {
 }
 
 type authResponse struct {
-	User  *model.User `json:"user"`
-	Token string      `json:"token"`
+	User *model.User `json:"user"`
+	*service.TokenPair
+	// RecoveryCodes — коды восстановления, если второй фактор подключён при этом входе.
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+}
+
+// challengeResponse — пароль принят, нужен код второго фактора.
+type challengeResponse struct {
+	MFARequired bool `json:"mfaRequired"`
+	*service.LoginChallenge
+}
+
+type secondFactorRequest struct {
+	MFAToken string `json:"mfaToken"`
+	Code     string `json:"code"`
+}
+
+type codeRequest struct {
+	Code string `json:"code"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+type changePasswordRequest struct {
+	Email       string `json:"email"`
+	Password    string `json:"password"`
+	NewPassword string `json:"newPassword"`
+	// Code — код второго фактора, если он у пользователя включён.
+	Code string `json:"code"`
+}
+
+type resetRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
 }
 
 // Register регистрирует обычного пользователя‑покупателя.
@@ -55,18 +100,11 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusBadRequest)
 		return
 	}
-
-	token, err := h.createToken(u)
-	if err != nil {
-		http.Error(w, `{"error":"failed to create token"}`, http.StatusInternalServerError)
-		return
+	// Регистрация не зависит от почты: письмо можно запросить повторно.
+	if err := h.accounts.SendVerification(r.Context(), u); err != nil {
+		log.Printf("[mail] failed to send verification to user %d: %v", u.ID, err)
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(&authResponse{
-		User:  u,
-		Token: token,
-	})
+	h.finishLogin(w, r, u)
 }
 
 // Login выполняет вход пользователя и возвращает JWT.
@@ -84,33 +122,390 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"email and password are required"}`, http.StatusBadRequest)
 		return
 	}
+	if !h.allowAttempt(w, r, req.Email) {
+		return
+	}
 
 	u, err := h.svc.Authenticate(r.Context(), req.Email, req.Password)
+	if !h.recordAttempt(w, r, req.Email, err) {
+		return
+	}
+	if errors.Is(err, service.ErrAccountDisabled) {
+		writeError(w, http.StatusForbidden, "account is disabled")
+		return
+	}
+	if errors.Is(err, service.ErrPasswordChangeRequired) {
+		// Клиент должен вызвать /api/auth/change-password с тем же паролем.
+		writeJSON(w, http.StatusForbidden, map[string]interface{}{
+			"error":                  "password change required",
+			"passwordChangeRequired": true,
+		})
+		return
+	}
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	h.finishLogin(w, r, u)
+}
+
+// LoginSecondFactor обрабатывает POST /api/auth/login/2fa {"mfaToken","code"}:
+// второй шаг входа. code — код из приложения-аутентификатора или код
+// восстановления. JWT выдаётся только здесь.
+func (h *AuthHandler) LoginSecondFactor(w http.ResponseWriter, r *http.Request) {
+	var req secondFactorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	u, err := h.svc.ChallengeUser(r.Context(), req.MFAToken)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if !h.allowAttempt(w, r, u.Email) {
+		return
+	}
+	codes, err := h.svc.CompleteSecondFactor(r.Context(), u, req.Code)
+	if errors.Is(err, service.ErrInvalidCode) {
+		// Подбор кодов ограничивается так же, как подбор паролей.
+		err = h.guard.Failure(r.Context(), u.Email, h.clientInfo(r).IP)
+		if err == nil {
+			err = service.ErrInvalidCode
+		}
+	}
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	// Счётчик неудач сбрасывается только после второго фактора, иначе знающий
+	// пароль мог бы обнулять его между попытками подбора кода.
+	if err := h.guard.Success(r.Context(), u.Email); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	h.issue(w, r, u, codes)
+}
+
+// ChangePassword обрабатывает POST /api/auth/change-password
+// {"email","password","newPassword","code"}: меняет временный пароль и сразу
+// выполняет вход. Нужен тем, кому пароль выдали и кто не может войти без его
+// смены; code обязателен, если у пользователя включён второй фактор.
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	var req changePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if !h.allowAttempt(w, r, req.Email) {
+		return
+	}
+	u, err := h.svc.ChangePassword(r.Context(), req.Email, req.Password, req.NewPassword, req.Code)
+	if !h.recordAttempt(w, r, req.Email, err) {
+		return
+	}
+	var verr *service.ValidationError
+	switch {
+	case errors.Is(err, service.ErrAccountDisabled):
+		writeError(w, http.StatusForbidden, "account is disabled")
+		return
+	case errors.Is(err, service.ErrSecondFactorRequired):
+		writeJSON(w, http.StatusForbidden, map[string]interface{}{
+			"error":        err.Error(),
+			"codeRequired": true,
+		})
+		return
+	case errors.As(err, &verr):
+		writeError(w, http.StatusBadRequest, verr.Msg)
+		return
+	case err != nil:
+		writeServiceError(w, err)
+		return
+	}
+	if !u.TOTPEnabled {
+		h.finishLogin(w, r, u)
+		return
+	}
+	// Второй фактор уже проверен вместе со сменой пароля.
+	if err := h.guard.Success(r.Context(), u.Email); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	h.issue(w, r, u, nil)
+}
+
+// Refresh обменивает refresh-токен на новую пару токенов (старый больше не действует).
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	var req refreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid json"}`, http.StatusBadRequest)
+		return
+	}
+	pair, u, err := h.tokens.Refresh(r.Context(), req.RefreshToken)
+	if errors.Is(err, service.ErrInvalidRefreshToken) {
+		http.Error(w, `{"error":"invalid refresh token"}`, http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, &authResponse{User: u, TokenPair: pair})
+}
+
+// Logout завершает сессию: по refresh-токену из тела или по access-токену из
+// заголовка Authorization.
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	var req refreshRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"error":"invalid json"}`, http.StatusBadRequest)
+			return
+		}
+	}
+	sid := service.SessionFromRefreshToken(req.RefreshToken)
+	if sid == "" {
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			if id, err := middleware.ParseToken(bearer, h.jwtSecret); err == nil {
+				sid = id.SessionID
+			}
+		}
+	}
+	if err := h.tokens.Logout(r.Context(), sid); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Verify обрабатывает POST /api/auth/verify {"token"}: подтверждает email по
+// ссылке из письма.
+func (h *AuthHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	var req resetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	u, err := h.accounts.Verify(r.Context(), req.Token)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, u)
+}
+
+// ResendVerification обрабатывает POST /api/auth/verify/resend: повторно
+// отправляет письмо подтверждения текущему пользователю.
+func (h *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	u, err := h.svc.GetByID(r.Context(), actorFrom(r).UserID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if u.EmailVerified {
+		writeError(w, http.StatusConflict, "email already verified")
+		return
+	}
+	if err := h.accounts.SendVerification(r.Context(), u); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Forgot обрабатывает POST /api/auth/forgot {"email"}. Ответ всегда 202,
+// есть такой пользователь или нет.
+func (h *AuthHandler) Forgot(w http.ResponseWriter, r *http.Request) {
+	var req authRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if req.Email == "" {
+		writeError(w, http.StatusBadRequest, "email is required")
+		return
+	}
+	if err := h.accounts.Forgot(r.Context(), req.Email); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Reset обрабатывает POST /api/auth/reset {"token","password"}: задаёт новый
+// пароль по ссылке из письма и завершает все сессии пользователя.
+func (h *AuthHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	var req resetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err := h.accounts.Reset(r.Context(), req.Token, req.Password); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// EnrollTOTP обрабатывает POST /api/auth/2fa/enroll: выдаёт секрет для
+// приложения-аутентификатора.
+func (h *AuthHandler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	enr, err := h.svc.BeginTOTP(r.Context(), actorFrom(r).UserID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, enr)
+}
+
+// ConfirmTOTP обрабатывает POST /api/auth/2fa/confirm {"code"}: включает
+// второй фактор и возвращает коды восстановления.
+func (h *AuthHandler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	var req codeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	codes, err := h.svc.ConfirmTOTP(r.Context(), actorFrom(r).UserID, req.Code)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string][]string{"recoveryCodes": codes})
+}
+
+// DisableTOTP обрабатывает POST /api/auth/2fa/disable {"code"}.
+func (h *AuthHandler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	var req codeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err := h.svc.DisableTOTP(r.Context(), actorFrom(r).UserID, req.Code); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RecoveryCodes обрабатывает POST /api/auth/2fa/recovery-codes {"code"}:
+// выдаёт новые коды восстановления, старые перестают действовать.
+func (h *AuthHandler) RecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	var req codeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	codes, err := h.svc.RegenerateRecoveryCodes(r.Context(), actorFrom(r).UserID, req.Code)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string][]string{"recoveryCodes": codes})
+}
+
+// finishLogin завершает вход после проверки пароля: выдаёт токены или, если
+// нужен второй фактор, токен для второго шага. Счётчик неудачных попыток
+// сбрасывается только вместе с выдачей токенов.
+func (h *AuthHandler) finishLogin(w http.ResponseWriter, r *http.Request, u *model.User) {
+	ch, err := h.svc.StartSecondFactor(r.Context(), u)
 	if err != nil {
-		http.Error(w, `{"error":"invalid credentials"}`, http.StatusUnauthorized)
+		writeServiceError(w, err)
 		return
 	}
+	if ch != nil {
+		writeJSON(w, http.StatusOK, &challengeResponse{MFARequired: true, LoginChallenge: ch})
+		return
+	}
+	if err := h.guard.Success(r.Context(), u.Email); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	h.issue(w, r, u, nil)
+}
 
-	token, err := h.createToken(u)
+func (h *AuthHandler) issue(w http.ResponseWriter, r *http.Request, u *model.User, recoveryCodes []string) {
+	pair, err := h.tokens.Issue(r.Context(), u, h.clientInfo(r))
 	if err != nil {
 		http.Error(w, `{"error":"failed to create token"}`, http.StatusInternalServerError)
 		return
 	}
+	writeJSON(w, http.StatusOK, &authResponse{User: u, TokenPair: pair, RecoveryCodes: recoveryCodes})
+}
+
+// Unlock обрабатывает POST /api/users/{id}/unlock (admin): снимает блокировку
+// входа после неудачных попыток.
+func (h *AuthHandler) Unlock(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+	if err := h.guard.Unlock(r.Context(), actorFrom(r), userID); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// allowAttempt проверяет, не заблокированы ли попытки входа для email и IP.
+// Если заблокированы, отвечает 429 с Retry-After и возвращает false.
+func (h *AuthHandler) allowAttempt(w http.ResponseWriter, r *http.Request, email string) bool {
+	err := h.guard.Check(r.Context(), email, h.clientInfo(r).IP)
+	var terr *service.ThrottledError
+	if errors.As(err, &terr) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(terr.RetryAfter.Seconds()))))
+		writeError(w, http.StatusTooManyRequests, terr.Error())
+		return false
+	}
+	if err != nil {
+		writeServiceError(w, err)
+		return false
+	}
+	return true
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(&authResponse{
-		User:  u,
-		Token: token,
-	})
+// recordAttempt учитывает результат проверки пароля: неверный пароль или код
+// второго фактора увеличивает счётчики. Верный пароль счётчик не сбрасывает — это делает
+// finishLogin, когда вход пройден полностью. При сбое учёта отвечает сам и
+// возвращает false.
+func (h *AuthHandler) recordAttempt(w http.ResponseWriter, r *http.Request, email string, authErr error) bool {
+	var err error
+	if errors.Is(authErr, service.ErrInvalidCredentials) || errors.Is(authErr, service.ErrInvalidCode) {
+		err = h.guard.Failure(r.Context(), email, h.clientInfo(r).IP)
+	}
+	if err != nil {
+		writeServiceError(w, err)
+		return false
+	}
+	return true
 }
 
-func (h *AuthHandler) createToken(u *model.User) (string, error) {
-	claims := jwt.MapClaims{
-		"sub":  u.ID,
-		"role": u.Role,
-		"exp":  time.Now().Add(24 * time.Hour).Unix(),
+// RevokeSessions обрабатывает DELETE /api/users/{id}/sessions (admin): все
+// сессии пользователя завершаются, его токены перестают приниматься сразу.
+func (h *AuthHandler) RevokeSessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid user id")
+		return
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(h.jwtSecret)
+	n, err := h.tokens.RevokeAll(r.Context(), actorFrom(r), userID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"revoked": n})
 }
 
+// clientInfo описывает устройство, с которого выполнен вход.
+func (h *AuthHandler) clientInfo(r *http.Request) service.ClientInfo {
+	return service.ClientInfo{UserAgent: r.UserAgent(), IP: h.proxies.ClientIP(r)}
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"cinema-system/middleware"
+	"cinema-system/service"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// BookingHandler обрабатывает /api/bookings. Все запросы требуют авторизации.
+type BookingHandler struct {
+	svc *service.BookingService
+}
+
+func NewBookingHandler(svc *service.BookingService) *BookingHandler {
+	return &BookingHandler{svc: svc}
+}
+
+type createBookingRequest struct {
+	SessionID    int                     `json:"sessionId"`
+	Tickets      []service.TicketRequest `json:"tickets"`
+	AgeConfirmed bool                    `json:"ageConfirmed"` // кассир проверил возраст покупателя
+}
+
+func (h *BookingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/bookings"), "/")
+
+	if path == "" {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		h.create(w, r)
+		return
+	}
+
+	id, err := strconv.Atoi(path)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	h.getByID(w, r, id)
+}
+
+func (h *BookingHandler) create(w http.ResponseWriter, r *http.Request) {
+	var req createBookingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	b, err := h.svc.Create(r.Context(), actorFrom(r), req.SessionID, req.Tickets, req.AgeConfirmed)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, b)
+}
+
+func (h *BookingHandler) getByID(w http.ResponseWriter, r *http.Request, id int) {
+	b, err := h.svc.Get(r.Context(), actorFrom(r), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, b)
+}
+
+// actorFrom достаёт пользователя, которого положил в контекст middleware авторизации.
+func actorFrom(r *http.Request) service.Actor {
+	id, _ := middleware.IdentityFromContext(r.Context())
+	return service.Actor{UserID: id.UserID, Role: id.Role, Permissions: id.Permissions}
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"cinema-system/service"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// CancellationHandler обрабатывает POST /api/bookings/{id}/cancel: покупатель
+// отменяет своё бронирование, кассир или админ — любое.
+type CancellationHandler struct {
+	svc *service.CancellationService
+}
+
+func NewCancellationHandler(svc *service.CancellationService) *CancellationHandler {
+	return &CancellationHandler{svc: svc}
+}
+
+type cancelRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (h *CancellationHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid booking id")
+		return
+	}
+	var req cancelRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+	}
+	res, err := h.svc.Cancel(r.Context(), actorFrom(r), id, req.Reason)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
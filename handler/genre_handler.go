@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"cinema-system/model"
+	"cinema-system/service"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// GenreHandler обрабатывает /api/genres: список, получение, создание, обновление, удаление.
+type GenreHandler struct {
+	svc *service.GenreService
+}
+
+func NewGenreHandler(svc *service.GenreService) *GenreHandler {
+	return &GenreHandler{svc: svc}
+}
+
+func (h *GenreHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/genres"), "/")
+
+	if path == "" {
+		switch r.Method {
+		case http.MethodGet:
+			h.list(w, r)
+		case http.MethodPost:
+			h.create(w, r)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+		return
+	}
+
+	id, err := strconv.Atoi(path)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		h.getByID(w, r, id)
+	case http.MethodPut:
+		h.update(w, r, id)
+	case http.MethodDelete:
+		h.delete(w, r, id)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (h *GenreHandler) list(w http.ResponseWriter, r *http.Request) {
+	genres, err := h.svc.GetAll(r.Context())
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if genres == nil {
+		genres = []*model.Genre{}
+	}
+	writeJSON(w, http.StatusOK, genres)
+}
+
+func (h *GenreHandler) getByID(w http.ResponseWriter, r *http.Request, id int) {
+	genre, err := h.svc.GetByID(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, genre)
+}
+
+func (h *GenreHandler) create(w http.ResponseWriter, r *http.Request) {
+	var genre model.Genre
+	if err := json.NewDecoder(r.Body).Decode(&genre); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	created, err := h.svc.Create(r.Context(), &genre)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (h *GenreHandler) update(w http.ResponseWriter, r *http.Request, id int) {
+	var genre model.Genre
+	if err := json.NewDecoder(r.Body).Decode(&genre); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	genre.ID = id
+	if err := h.svc.Update(r.Context(), &genre); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, &genre)
+}
+
+func (h *GenreHandler) delete(w http.ResponseWriter, r *http.Request, id int) {
+	if err := h.svc.Delete(r.Context(), id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"cinema-system/model"
+	"cinema-system/service"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// HallHandler обрабатывает /api/halls: список, получение, создание, обновление, удаление.
+type HallHandler struct {
+	svc *service.HallService
+}
+
+func NewHallHandler(svc *service.HallService) *HallHandler {
+	return &HallHandler{svc: svc}
+}
+
+func (h *HallHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/halls"), "/")
+
+	if path == "" {
+		switch r.Method {
+		case http.MethodGet:
+			h.list(w, r)
+		case http.MethodPost:
+			h.create(w, r)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+		return
+	}
+
+	id, err := strconv.Atoi(path)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		h.getByID(w, r, id)
+	case http.MethodPut:
+		h.update(w, r, id)
+	case http.MethodDelete:
+		h.delete(w, r, id)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (h *HallHandler) list(w http.ResponseWriter, r *http.Request) {
+	halls, err := h.svc.GetAll(r.Context())
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if halls == nil {
+		halls = []*model.Hall{}
+	}
+	writeJSON(w, http.StatusOK, halls)
+}
+
+func (h *HallHandler) getByID(w http.ResponseWriter, r *http.Request, id int) {
+	hall, err := h.svc.GetByID(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, hall)
+}
+
+func (h *HallHandler) create(w http.ResponseWriter, r *http.Request) {
+	var hall model.Hall
+	if err := json.NewDecoder(r.Body).Decode(&hall); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	created, err := h.svc.Create(r.Context(), &hall)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (h *HallHandler) update(w http.ResponseWriter, r *http.Request, id int) {
+	var hall model.Hall
+	if err := json.NewDecoder(r.Body).Decode(&hall); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	hall.ID = id
+	if err := h.svc.Update(r.Context(), &hall); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, &hall)
+}
+
+func (h *HallHandler) delete(w http.ResponseWriter, r *http.Request, id int) {
+	if err := h.svc.Delete(r.Context(), id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"cinema-system/model"
+	"cinema-system/service"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// HoldHandler обрабатывает удержания мест:
+//
+//	POST   /api/sessions/{id}/holds – удержать места на сеанс
+//	GET    /api/holds/{id}          – получить удержание
+//	DELETE /api/holds/{id}          – освободить места
+//	POST   /api/holds/{id}/booking  – оформить бронирование из удержания
+type HoldHandler struct {
+	svc *service.HoldService
+}
+
+func NewHoldHandler(svc *service.HoldService) *HoldHandler {
+	return &HoldHandler{svc: svc}
+}
+
+type holdRequest struct {
+	Seats []model.SeatRef `json:"seats"`
+}
+
+type convertHoldRequest struct {
+	Tickets      []service.TicketRequest `json:"tickets"`
+	AgeConfirmed bool                    `json:"ageConfirmed"`
+}
+
+// CreateForSession обрабатывает POST /api/sessions/{id}/holds.
+func (h *HoldHandler) CreateForSession(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid session id")
+		return
+	}
+	var req holdRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	hold, err := h.svc.Create(r.Context(), actorFrom(r), sessionID, req.Seats)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, hold)
+}
+
+func (h *HoldHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/holds"), "/"), "/")
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		hold, err := h.svc.Get(r.Context(), actorFrom(r), id)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, hold)
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		if err := h.svc.Release(r.Context(), actorFrom(r), id); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case len(parts) == 2 && parts[1] == "booking" && r.Method == http.MethodPost:
+		var req convertHoldRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid json")
+				return
+			}
+		}
+		b, err := h.svc.Convert(r.Context(), actorFrom(r), id, req.Tickets, req.AgeConfirmed)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, b)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"cinema-system/middleware"
+	"cinema-system/service"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// MeHandler обрабатывает /api/me — личный кабинет текущего пользователя.
+// Пользователь всегда берётся из токена (claim "sub"), id в запросе не передаётся.
+type MeHandler struct {
+	svc *service.ProfileService
+}
+
+func NewMeHandler(svc *service.ProfileService) *MeHandler {
+	return &MeHandler{svc: svc}
+}
+
+// Get обрабатывает GET /api/me.
+func (h *MeHandler) Get(w http.ResponseWriter, r *http.Request) {
+	u, err := h.svc.Get(r.Context(), actorFrom(r))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, u)
+}
+
+// Update обрабатывает PATCH /api/me {"name","language","email","password","currentPassword"}.
+// Смена email и пароля требует текущего пароля.
+func (h *MeHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req service.ProfileUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	id, _ := middleware.IdentityFromContext(r.Context())
+	u, err := h.svc.Update(r.Context(), actorFrom(r), id.SessionID, req)
+	if err != nil {
+		writeMeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, u)
+}
+
+// Delete обрабатывает DELETE /api/me {"password"}.
+func (h *MeHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err := h.svc.Delete(r.Context(), actorFrom(r), req.Password); err != nil {
+		writeMeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Bookings обрабатывает GET /api/me/bookings.
+func (h *MeHandler) Bookings(w http.ResponseWriter, r *http.Request) {
+	out, err := h.svc.Bookings(r.Context(), actorFrom(r))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// writeMeError отвечает 403 на неверный текущий пароль: 401 клиент принял бы
+// за истёкший токен.
+func writeMeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, service.ErrInvalidCredentials) {
+		writeError(w, http.StatusForbidden, "current password is incorrect")
+		return
+	}
+	writeServiceError(w, err)
+}
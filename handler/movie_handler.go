@@ -54,20 +54,71 @@ func (h *MovieHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (h *MovieHandler) list(w http.ResponseWriter, _ *http.Request) {
-	movies, err := h.svc.GetAll()
-	if err != nil {
-		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+// list handles GET /api/movies?status=&q=&genre=&minRating=&maxRating=&minDuration=&maxDuration=&sort=&order=&cursor=&limit=
+func (h *MovieHandler) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := service.MovieQuery{
+		Search: query.Get("q"),
+		Sort:   query.Get("sort"),
+		Order:  query.Get("order"),
+		Cursor: query.Get("cursor"),
+	}
+	// status is a comma-separated list of statuses or "all".
+	if status := strings.TrimSpace(query.Get("status")); status == "all" {
+		q.AllStatuses = true
+	} else if status != "" {
+		for _, st := range strings.Split(status, ",") {
+			q.Statuses = append(q.Statuses, strings.TrimSpace(st))
+		}
+	}
+	var err error
+	if q.GenreID, err = queryInt(r, "genre"); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid genre")
+		return
+	}
+	if q.MinRating, err = queryFloat(r, "minRating"); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid minRating")
+		return
+	}
+	if q.MaxRating, err = queryFloat(r, "maxRating"); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid maxRating")
+		return
+	}
+	if q.MinDuration, err = queryInt(r, "minDuration"); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid minDuration")
+		return
+	}
+	if q.MaxDuration, err = queryInt(r, "maxDuration"); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid maxDuration")
+		return
+	}
+	if q.Limit, err = queryInt(r, "limit"); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid limit")
 		return
 	}
-	if movies == nil {
-		movies = []*model.Movie{}
+	page, err := h.svc.List(r.Context(), q)
+	if err != nil {
+		writeServiceError(w, err)
+		return
 	}
-	_ = json.NewEncoder(w).Encode(movies)
+	writeJSON(w, http.StatusOK, page)
 }
 
-func (h *MovieHandler) getByID(w http.ResponseWriter, _ *http.Request, id int) {
-	m, err := h.svc.GetByID(id)
+// getByID handles GET /api/movies/{id}; ?include=credits embeds cast and crew.
+func (h *MovieHandler) getByID(w http.ResponseWriter, r *http.Request, id int) {
+	var (
+		m   *model.Movie
+		err error
+	)
+	switch include := r.URL.Query().Get("include"); include {
+	case "":
+		m, err = h.svc.GetByID(id)
+	case "credits":
+		m, err = h.svc.GetWithCredits(r.Context(), id)
+	default:
+		writeError(w, http.StatusBadRequest, "invalid include")
+		return
+	}
 	if err != nil {
 		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
 		return
@@ -85,9 +136,9 @@ func (h *MovieHandler) create(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"invalid json"}`, http.StatusBadRequest)
 		return
 	}
-	created, err := h.svc.Create(&m)
+	created, err := h.svc.Create(r.Context(), &m)
 	if err != nil {
-		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusCreated)
@@ -101,14 +152,18 @@ func (h *MovieHandler) update(w http.ResponseWriter, r *http.Request, id int) {
 		return
 	}
 	m.ID = id
-	if err := h.svc.Update(&m); err != nil {
-		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+	if err := h.svc.Update(r.Context(), &m); err != nil {
+		writeServiceError(w, err)
 		return
 	}
 	_ = json.NewEncoder(w).Encode(&m)
 }
 
-func (h *MovieHandler) delete(w http.ResponseWriter, _ *http.Request, id int) {
-	_ = h.svc.Delete(id)
+// delete handles DELETE /api/movies/{id} by archiving the movie.
+func (h *MovieHandler) delete(w http.ResponseWriter, r *http.Request, id int) {
+	if err := h.svc.Delete(r.Context(), id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
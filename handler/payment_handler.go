@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"cinema-system/payment"
+	"cinema-system/service"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// maxWebhookBody ограничивает размер тела вебхука.
+const maxWebhookBody = 64 << 10
+
+// PaymentHandler обрабатывает оплату бронирований:
+//
+//	POST /api/bookings/{id}/payments – начать оплату (auth)
+//	GET  /api/payments/{id}          – статус оплаты (auth)
+//	POST /api/payments/webhook       – вебхук провайдера (подпись X-Payment-Signature)
+//	POST /api/payments/fake/script   – сценарий тестового провайдера (admin)
+type PaymentHandler struct {
+	svc *service.PaymentService
+}
+
+func NewPaymentHandler(svc *service.PaymentService) *PaymentHandler {
+	return &PaymentHandler{svc: svc}
+}
+
+type payRequest struct {
+	Card string `json:"card"`
+}
+
+type scriptRequest struct {
+	Outcomes []payment.Outcome `json:"outcomes"`
+}
+
+// Pay обрабатывает POST /api/bookings/{id}/payments.
+func (h *PaymentHandler) Pay(w http.ResponseWriter, r *http.Request) {
+	bookingID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid booking id")
+		return
+	}
+	var req payRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+	}
+	intent, err := h.svc.Pay(r.Context(), actorFrom(r), bookingID, req.Card)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, intent)
+}
+
+// Get обрабатывает GET /api/payments/{id}.
+func (h *PaymentHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	intent, err := h.svc.Get(r.Context(), actorFrom(r), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, intent)
+}
+
+// Webhook обрабатывает POST /api/payments/webhook.
+func (h *PaymentHandler) Webhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "cannot read body")
+		return
+	}
+	err = h.svc.HandleWebhook(r.Context(), body, r.Header.Get(payment.SignatureHeader))
+	if errors.Is(err, service.ErrBadSignature) {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Script обрабатывает POST /api/payments/fake/script.
+func (h *PaymentHandler) Script(w http.ResponseWriter, r *http.Request) {
+	var req scriptRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err := h.svc.ScriptFake(req.Outcomes); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"cinema-system/model"
+	"cinema-system/service"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// PersonHandler обрабатывает /api/people: актёры и съёмочная группа, их фильмы.
+type PersonHandler struct {
+	svc *service.PersonService
+}
+
+func NewPersonHandler(svc *service.PersonService) *PersonHandler {
+	return &PersonHandler{svc: svc}
+}
+
+// List обрабатывает GET /api/people?q=&page=&pageSize=
+func (h *PersonHandler) List(w http.ResponseWriter, r *http.Request) {
+	q := service.PersonQuery{Search: r.URL.Query().Get("q")}
+	var err error
+	if q.Page, err = queryInt(r, "page"); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid page")
+		return
+	}
+	if q.PageSize, err = queryInt(r, "pageSize"); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid pageSize")
+		return
+	}
+	page, err := h.svc.List(r.Context(), q)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// Get обрабатывает GET /api/people/{id}.
+func (h *PersonHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := personID(w, r)
+	if !ok {
+		return
+	}
+	p, err := h.svc.Get(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+// Movies обрабатывает GET /api/people/{id}/movies.
+func (h *PersonHandler) Movies(w http.ResponseWriter, r *http.Request) {
+	id, ok := personID(w, r)
+	if !ok {
+		return
+	}
+	items, err := h.svc.Movies(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// Create обрабатывает POST /api/people.
+func (h *PersonHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var p model.Person
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	created, err := h.svc.Create(r.Context(), &p)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// Update обрабатывает PUT /api/people/{id}.
+func (h *PersonHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := personID(w, r)
+	if !ok {
+		return
+	}
+	var p model.Person
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	p.ID = id
+	if err := h.svc.Update(r.Context(), &p); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, &p)
+}
+
+// Delete обрабатывает DELETE /api/people/{id}.
+func (h *PersonHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := personID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.svc.Delete(r.Context(), id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func personID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid person id")
+		return 0, false
+	}
+	return id, true
+}
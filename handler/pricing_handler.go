@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"cinema-system/model"
+	"cinema-system/service"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// PricingHandler обрабатывает /api/pricing-rules (управление правилами, admin)
+// и GET /api/sessions/{id}/prices (цены на сеанс для интерфейса).
+type PricingHandler struct {
+	svc *service.PricingService
+}
+
+func NewPricingHandler(svc *service.PricingService) *PricingHandler {
+	return &PricingHandler{svc: svc}
+}
+
+func (h *PricingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/pricing-rules"), "/")
+
+	if path == "" {
+		switch r.Method {
+		case http.MethodGet:
+			rules, err := h.svc.GetAll(r.Context())
+			if err != nil {
+				writeServiceError(w, err)
+				return
+			}
+			if rules == nil {
+				rules = []*model.PricingRule{}
+			}
+			writeJSON(w, http.StatusOK, rules)
+		case http.MethodPost:
+			var rule model.PricingRule
+			if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid json")
+				return
+			}
+			created, err := h.svc.Create(r.Context(), &rule)
+			if err != nil {
+				writeServiceError(w, err)
+				return
+			}
+			writeJSON(w, http.StatusCreated, created)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+		return
+	}
+
+	id, err := strconv.Atoi(path)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		rule, err := h.svc.GetByID(r.Context(), id)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, rule)
+	case http.MethodPut:
+		var rule model.PricingRule
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+		rule.ID = id
+		if err := h.svc.Update(r.Context(), &rule); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, &rule)
+	case http.MethodDelete:
+		if err := h.svc.Delete(r.Context(), id); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// SessionPrices обрабатывает GET /api/sessions/{id}/prices.
+func (h *PricingHandler) SessionPrices(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid session id")
+		return
+	}
+	list, err := h.svc.PriceList(r.Context(), sessionID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
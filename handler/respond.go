@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"cinema-system/service"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// writeJSON отдаёт v как JSON с указанным статусом.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError отдаёт ошибку в формате {"error": "..."}.
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+// writeServiceError переводит ошибку сервисного слоя в HTTP-ответ.
+func writeServiceError(w http.ResponseWriter, err error) {
+	var (
+		verr *service.ValidationError
+		cerr *service.ConflictError
+	)
+	switch {
+	case errors.As(err, &verr):
+		writeError(w, http.StatusBadRequest, verr.Msg)
+	case errors.As(err, &cerr):
+		body := map[string]interface{}{"error": cerr.Msg}
+		if cerr.Conflict != nil {
+			body["conflict"] = cerr.Conflict
+		}
+		writeJSON(w, http.StatusConflict, body)
+	case errors.Is(err, service.ErrNotFound):
+		writeError(w, http.StatusNotFound, "not found")
+	case errors.Is(err, service.ErrInvalidCredentials):
+		writeError(w, http.StatusUnauthorized, "invalid credentials")
+	case errors.Is(err, service.ErrInvalidCode):
+		writeError(w, http.StatusUnauthorized, "invalid verification code")
+	default:
+		log.Printf("internal error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal")
+	}
+}
+
+// queryInt читает целочисленный query-параметр; пустое значение даёт 0.
+func queryInt(r *http.Request, name string) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(v)
+}
+
+// queryFloat читает дробный query-параметр; пустое значение даёт nil.
+func queryFloat(r *http.Request, name string) (*float64, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
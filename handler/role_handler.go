@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"cinema-system/model"
+	"cinema-system/service"
+	"encoding/json"
+	"net/http"
+)
+
+// RoleHandler обрабатывает /api/roles и /api/permissions: настройку того,
+// какие права есть у каждой роли.
+type RoleHandler struct {
+	svc *service.RoleService
+}
+
+func NewRoleHandler(svc *service.RoleService) *RoleHandler {
+	return &RoleHandler{svc: svc}
+}
+
+type roleRequest struct {
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+	Require2FA  bool     `json:"require2fa"`
+}
+
+// Permissions обрабатывает GET /api/permissions — все права, которые можно выдать.
+func (h *RoleHandler) Permissions(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, model.Permissions)
+}
+
+// List обрабатывает GET /api/roles.
+func (h *RoleHandler) List(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.svc.List(r.Context())
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, roles)
+}
+
+// Save обрабатывает PUT /api/roles/{name} {"description","permissions":[...],"require2fa"}:
+// создаёт роль или заменяет её права.
+func (h *RoleHandler) Save(w http.ResponseWriter, r *http.Request) {
+	var req roleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	role, err := h.svc.Save(r.Context(), actorFrom(r), r.PathValue("name"), req.Description, req.Permissions, req.Require2FA)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, role)
+}
+
+// Delete обрабатывает DELETE /api/roles/{name}.
+func (h *RoleHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.svc.Delete(r.Context(), actorFrom(r), r.PathValue("name")); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"cinema-system/realtime"
+	"cinema-system/service"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SeatHandler отдаёт схему мест сеанса и поток её изменений.
+type SeatHandler struct {
+	svc    *service.SeatService
+	events realtime.Subscriber
+}
+
+func NewSeatHandler(svc *service.SeatService, events realtime.Subscriber) *SeatHandler {
+	return &SeatHandler{svc: svc, events: events}
+}
+
+// SeatMap обрабатывает GET /api/sessions/{id}/seats.
+// Ответ снабжается ETag, поэтому страница может часто опрашивать схему с
+// If-None-Match и получать 304 без тела, пока ничего не изменилось.
+func (h *SeatHandler) SeatMap(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid session id")
+		return
+	}
+	m, err := h.svc.SeatMap(r.Context(), sessionID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	body, err := json.Marshal(m)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+// etagMatches проверяет заголовок If-None-Match (список тегов через запятую или "*").
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// sseKeepAlive — период комментариев-пингов, чтобы прокси не закрывали тихое соединение.
+const sseKeepAlive = 25 * time.Second
+
+// Stream обрабатывает GET /api/sessions/{id}/seats/stream — Server-Sent Events.
+// Сначала отправляется событие snapshot с полной схемой, затем события seats
+// с изменениями (realtime.SeatEvent) по мере удержаний и покупок.
+func (h *SeatHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid session id")
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	// Подписываемся до снимка, чтобы не потерять изменения между ними.
+	events, cancel := h.events.Subscribe(sessionID)
+	defer cancel()
+
+	snapshot, err := h.svc.SeatMap(r.Context(), sessionID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := writeSSE(w, "snapshot", snapshot); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := writeSSE(w, "seats", ev); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func writeSSE(w http.ResponseWriter, event string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"cinema-system/model"
+	"cinema-system/service"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// SessionHandler обрабатывает /api/sessions: расписание сеансов.
+type SessionHandler struct {
+	svc *service.SessionService
+}
+
+func NewSessionHandler(svc *service.SessionService) *SessionHandler {
+	return &SessionHandler{svc: svc}
+}
+
+func (h *SessionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/sessions"), "/")
+
+	if path == "" {
+		switch r.Method {
+		case http.MethodGet:
+			h.list(w, r)
+		case http.MethodPost:
+			h.create(w, r)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+		return
+	}
+
+	id, err := strconv.Atoi(path)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		h.getByID(w, r, id)
+	case http.MethodPut:
+		h.update(w, r, id)
+	case http.MethodDelete:
+		h.delete(w, r, id)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// list: GET /api/sessions?date=YYYY-MM-DD&movieId=1&hallId=2
+func (h *SessionHandler) list(w http.ResponseWriter, r *http.Request) {
+	q := service.SessionQuery{Date: r.URL.Query().Get("date")}
+	var err error
+	if q.MovieID, err = queryInt(r, "movieId"); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid movieId")
+		return
+	}
+	if q.HallID, err = queryInt(r, "hallId"); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid hallId")
+		return
+	}
+
+	sessions, err := h.svc.List(r.Context(), q)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if sessions == nil {
+		sessions = []*model.Session{}
+	}
+	writeJSON(w, http.StatusOK, sessions)
+}
+
+func (h *SessionHandler) getByID(w http.ResponseWriter, r *http.Request, id int) {
+	sess, err := h.svc.GetByID(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, sess)
+}
+
+func (h *SessionHandler) create(w http.ResponseWriter, r *http.Request) {
+	var sess model.Session
+	if err := json.NewDecoder(r.Body).Decode(&sess); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	created, err := h.svc.Create(r.Context(), &sess)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (h *SessionHandler) update(w http.ResponseWriter, r *http.Request, id int) {
+	var sess model.Session
+	if err := json.NewDecoder(r.Body).Decode(&sess); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	sess.ID = id
+	if err := h.svc.Update(r.Context(), &sess); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, &sess)
+}
+
+func (h *SessionHandler) delete(w http.ResponseWriter, r *http.Request, id int) {
+	if err := h.svc.Delete(r.Context(), id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
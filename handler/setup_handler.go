@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"cinema-system/service"
+	"encoding/json"
+	"net/http"
+)
+
+// SetupHandler обрабатывает POST /api/setup — создание первого администратора
+// по одноразовому токену из лога сервера.
+type SetupHandler struct {
+	svc *service.SetupService
+}
+
+func NewSetupHandler(svc *service.SetupService) *SetupHandler {
+	return &SetupHandler{svc: svc}
+}
+
+type setupRequest struct {
+	Token    string `json:"token"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+func (h *SetupHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req setupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	u, err := h.svc.Complete(r.Context(), req.Token, req.Name, req.Email, req.Password)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, u)
+}
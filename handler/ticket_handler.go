@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"cinema-system/service"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// TicketHandler обрабатывает электронные билеты:
+//
+//	GET  /api/tickets/{id}/qr.png        – QR-код билета (владелец или сотрудник)
+//	GET  /api/bookings/{id}/ticket.pdf   – PDF с билетами (владелец или сотрудник)
+//	GET  /api/bookings/{id}/receipt.pdf  – чек для печати (кассир или админ)
+//	POST /api/checkin                    – проход по билету (кассир или контролёр)
+type TicketHandler struct {
+	svc *service.TicketService
+}
+
+func NewTicketHandler(svc *service.TicketService) *TicketHandler {
+	return &TicketHandler{svc: svc}
+}
+
+type checkinRequest struct {
+	Token     string `json:"token"`
+	SessionID int    `json:"sessionId"`
+}
+
+// QR обрабатывает GET /api/tickets/{id}/qr.png.
+func (h *TicketHandler) QR(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid ticket id")
+		return
+	}
+	png, err := h.svc.QR(r.Context(), actorFrom(r), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "private, no-store")
+	_, _ = w.Write(png)
+}
+
+// TicketPDF обрабатывает GET /api/bookings/{id}/ticket.pdf.
+func (h *TicketHandler) TicketPDF(w http.ResponseWriter, r *http.Request) {
+	h.servePDF(w, r, "tickets", h.svc.TicketPDF)
+}
+
+// ReceiptPDF обрабатывает GET /api/bookings/{id}/receipt.pdf.
+func (h *TicketHandler) ReceiptPDF(w http.ResponseWriter, r *http.Request) {
+	h.servePDF(w, r, "receipt", h.svc.ReceiptPDF)
+}
+
+func (h *TicketHandler) servePDF(w http.ResponseWriter, r *http.Request, name string, render func(context.Context, service.Actor, int) ([]byte, error)) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid booking id")
+		return
+	}
+	doc, err := render(r.Context(), actorFrom(r), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s-%d.pdf"`, name, id))
+	w.Header().Set("Cache-Control", "private, no-store")
+	_, _ = w.Write(doc)
+}
+
+// CheckIn обрабатывает POST /api/checkin.
+func (h *TicketHandler) CheckIn(w http.ResponseWriter, r *http.Request) {
+	var req checkinRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	t, err := h.svc.CheckIn(r.Context(), actorFrom(r), req.Token, req.SessionID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"cinema-system/service"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// UserHandler обрабатывает /api/users (только admin): список с поиском,
+// карточку пользователя, смену роли, отключение и сброс пароля.
+type UserHandler struct {
+	svc *service.UserAdminService
+}
+
+func NewUserHandler(svc *service.UserAdminService) *UserHandler {
+	return &UserHandler{svc: svc}
+}
+
+// List обрабатывает GET /api/users?q=&role=&active=&page=&pageSize=
+func (h *UserHandler) List(w http.ResponseWriter, r *http.Request) {
+	q := service.UserQuery{
+		Search: r.URL.Query().Get("q"),
+		Role:   r.URL.Query().Get("role"),
+	}
+	if v := r.URL.Query().Get("active"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid active")
+			return
+		}
+		disabled := !active
+		q.Disabled = &disabled
+	}
+	var err error
+	if q.Page, err = queryInt(r, "page"); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid page")
+		return
+	}
+	if q.PageSize, err = queryInt(r, "pageSize"); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid pageSize")
+		return
+	}
+	page, err := h.svc.List(r.Context(), q)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// Get обрабатывает GET /api/users/{id}.
+func (h *UserHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := userID(w, r)
+	if !ok {
+		return
+	}
+	u, err := h.svc.Get(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, u)
+}
+
+// SetRole обрабатывает PUT /api/users/{id}/role {"role":"cashier"}.
+func (h *UserHandler) SetRole(w http.ResponseWriter, r *http.Request) {
+	id, ok := userID(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	u, err := h.svc.SetRole(r.Context(), actorFrom(r), id, req.Role)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, u)
+}
+
+// Activate обрабатывает POST /api/users/{id}/activate.
+func (h *UserHandler) Activate(w http.ResponseWriter, r *http.Request) {
+	h.setActive(w, r, true)
+}
+
+// Deactivate обрабатывает POST /api/users/{id}/deactivate.
+func (h *UserHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
+	h.setActive(w, r, false)
+}
+
+func (h *UserHandler) setActive(w http.ResponseWriter, r *http.Request, active bool) {
+	id, ok := userID(w, r)
+	if !ok {
+		return
+	}
+	u, err := h.svc.SetActive(r.Context(), actorFrom(r), id, active)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, u)
+}
+
+// SetPassword обрабатывает PUT /api/users/{id}/password {"password":"..."}.
+func (h *UserHandler) SetPassword(w http.ResponseWriter, r *http.Request) {
+	id, ok := userID(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err := h.svc.SetPassword(r.Context(), actorFrom(r), id, req.Password); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ResetTOTP обрабатывает DELETE /api/users/{id}/2fa: выключает пользователю
+// второй фактор, если он потерял телефон и коды восстановления.
+func (h *UserHandler) ResetTOTP(w http.ResponseWriter, r *http.Request) {
+	id, ok := userID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.svc.ResetTOTP(r.Context(), actorFrom(r), id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// userID разбирает {id} из пути; при ошибке сам отвечает 400.
+func userID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid user id")
+		return 0, false
+	}
+	return id, true
+}
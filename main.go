@@ -1,16 +1,25 @@
 package main
 
 import (
+	"cinema-system/eticket"
 	"cinema-system/handler"
+	"cinema-system/mail"
 	"cinema-system/middleware"
+	"cinema-system/model"
+	"cinema-system/payment"
+	"cinema-system/realtime"
 	"cinema-system/repository"
 	"cinema-system/service"
 	"context"
+	"crypto/rand"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
+	_ "time/tzdata" // часовые пояса для CINEMA_TZ в контейнерах без tzdata
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -19,6 +28,10 @@ import (
 const port = ":8080"
 
 func main() {
+	adminEmail := flag.String("admin-email", os.Getenv("BOOTSTRAP_ADMIN_EMAIL"), "email of the initial administrator, created if there is none")
+	adminPassword := flag.String("admin-password", os.Getenv("BOOTSTRAP_ADMIN_PASSWORD"), "temporary password of the initial administrator")
+	flag.Parse()
+
 	// Подключение к MongoDB Atlas (или локальной MongoDB) через переменную окружения MONGODB_URI.
 	mongoURI := os.Getenv("MONGODB_URI")
 	if mongoURI == "" {
@@ -50,26 +63,361 @@ func main() {
 
 	// Пользователи и роли.
 	userRepo := repository.NewUserRepo(ctx, client, dbName)
-	userSvc := service.NewUserService(userRepo)
 
-	// Создаём дефолтных пользователей, если их ещё нет.
-	if err := userSvc.EnsureUserWithRole(ctx, "admin", "1234", "Admin", service.RoleAdmin); err != nil {
-		log.Fatalf("failed to ensure default admin: %v", err)
+	// Журнал аудита (кто что отменил, изменил и т.д.).
+	auditRepo, err := repository.NewAuditRepo(ctx, client, dbName)
+	if err != nil {
+		log.Fatalf("failed to initialise audit repository: %v", err)
+	}
+	auditSvc := service.NewAuditService(auditRepo)
+	auditHandler := handler.NewAuditHandler(auditSvc)
+
+	// Роли и их права хранятся в MongoDB и редактируются через /api/roles.
+	roleRepo, err := repository.NewRoleRepo(ctx, client, dbName)
+	if err != nil {
+		log.Fatalf("failed to initialise role repository: %v", err)
+	}
+	roleSvc := service.NewRoleService(roleRepo, userRepo, auditSvc)
+	if err := roleSvc.EnsureDefaults(ctx); err != nil {
+		log.Fatalf("failed to ensure default roles: %v", err)
 	}
-	if err := userSvc.EnsureUserWithRole(ctx, "cashier", "1234", "Cashier", service.RoleCashier); err != nil {
-		log.Fatalf("failed to ensure default cashier: %v", err)
+	roleHandler := handler.NewRoleHandler(roleSvc)
+
+	// Одноразовые токены: ссылки из писем и второй шаг входа.
+	userTokenRepo, err := repository.NewUserTokenRepo(ctx, client, dbName)
+	if err != nil {
+		log.Fatalf("failed to initialise user token repository: %v", err)
+	}
+
+	// Короткие access-токены (ACCESS_TOKEN_TTL_MINUTES, по умолчанию 15) и
+	// ротируемые refresh-токены (REFRESH_TOKEN_TTL_DAYS, по умолчанию 30).
+	accessTTL := 15 * time.Minute
+	if v := os.Getenv("ACCESS_TOKEN_TTL_MINUTES"); v != "" {
+		minutes, err := strconv.Atoi(v)
+		if err != nil || minutes <= 0 {
+			log.Fatalf("invalid ACCESS_TOKEN_TTL_MINUTES %q", v)
+		}
+		accessTTL = time.Duration(minutes) * time.Minute
+	}
+	refreshTTL := 30 * 24 * time.Hour
+	if v := os.Getenv("REFRESH_TOKEN_TTL_DAYS"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days <= 0 {
+			log.Fatalf("invalid REFRESH_TOKEN_TTL_DAYS %q", v)
+		}
+		refreshTTL = time.Duration(days) * 24 * time.Hour
+	}
+	authSessionRepo, err := repository.NewAuthSessionRepo(ctx, client, dbName)
+	if err != nil {
+		log.Fatalf("failed to initialise auth session repository: %v", err)
 	}
+	tokenSvc := service.NewTokenService(authSessionRepo, userRepo, auditSvc, jwtSecret, accessTTL, refreshTTL)
+	// Проверка access-токенов с учётом отозванных сессий и прав ролей.
+	auth := middleware.NewAuth(jwtSecret, tokenSvc, roleSvc)
 
-	authHandler := handler.NewAuthHandler(userSvc, jwtSecret)
+	userSvc := service.NewUserService(userRepo, userTokenRepo, roleSvc, tokenSvc, auditSvc)
+
+	// Кассир создаётся, только если задан в конфигурации.
+	if err := userSvc.EnsureUserWithRole(ctx, os.Getenv("BOOTSTRAP_CASHIER_EMAIL"), os.Getenv("BOOTSTRAP_CASHIER_PASSWORD"), "Cashier", service.RoleCashier); err != nil {
+		log.Fatalf("failed to ensure cashier: %v", err)
+	}
+
+	// Первый администратор: из -admin-email/-admin-password или
+	// BOOTSTRAP_ADMIN_EMAIL/BOOTSTRAP_ADMIN_PASSWORD (пароль придётся сменить
+	// при первом входе), иначе по одноразовому токену из лога через POST /api/setup.
+	// Учётные записи admin/cashier с паролем 1234 из прежних версий
+	// отключаются до того, как сервер начнёт принимать запросы.
+	setupSvc := service.NewSetupService(userRepo, tokenSvc, auditSvc)
+	if n, err := setupSvc.DisableLegacyAccounts(ctx); err != nil {
+		log.Fatalf("failed to disable default accounts: %v", err)
+	} else if n > 0 {
+		log.Printf("disabled %d account(s) that still had the old default password", n)
+	}
+	setupToken, err := setupSvc.Bootstrap(ctx, *adminEmail, *adminPassword)
+	if err != nil {
+		log.Fatalf("failed to bootstrap admin: %v", err)
+	}
+	if setupToken != "" {
+		log.Printf("no administrator yet: create one with POST /api/setup {\"token\":%q,\"email\":...,\"password\":...}", setupToken)
+	}
+	setupHandler := handler.NewSetupHandler(setupSvc)
+
+	// Почта: SMTP, если задан MAIL_SMTP_ADDR, иначе письма пишутся в лог и в
+	// каталог MAIL_OUTBOX_DIR (по умолчанию outbox) — для разработки.
+	mailFrom := os.Getenv("MAIL_FROM")
+	if mailFrom == "" {
+		mailFrom = "Cinema System <no-reply@cinema.local>"
+	}
+	var mailer mail.Mailer
+	if addr := os.Getenv("MAIL_SMTP_ADDR"); addr != "" {
+		mailer = mail.NewSMTPMailer(addr, os.Getenv("MAIL_SMTP_USER"), os.Getenv("MAIL_SMTP_PASSWORD"), mailFrom)
+	} else {
+		outbox := os.Getenv("MAIL_OUTBOX_DIR")
+		if outbox == "" {
+			outbox = "outbox"
+		}
+		mailer = mail.NewFileMailer(outbox, mailFrom)
+	}
+	// Адрес сайта для ссылок в письмах.
+	publicURL := os.Getenv("PUBLIC_URL")
+	if publicURL == "" {
+		publicURL = "http://localhost" + port
+	}
+	accountSvc := service.NewAccountService(userRepo, userTokenRepo, tokenSvc, auditSvc, mailer, publicURL)
+
+	// Ограничение неудачных входов: после LOGIN_LOCKOUT_ATTEMPTS (по умолчанию 10)
+	// ошибок подряд учётная запись блокируется на LOGIN_LOCKOUT_MINUTES (15).
+	accountPolicy, ipPolicy := service.DefaultAccountLoginPolicy, service.DefaultIPLoginPolicy
+	if v := os.Getenv("LOGIN_LOCKOUT_ATTEMPTS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= accountPolicy.FreeAttempts {
+			log.Fatalf("invalid LOGIN_LOCKOUT_ATTEMPTS %q (must be more than %d)", v, accountPolicy.FreeAttempts)
+		}
+		accountPolicy.LockAfter = n
+	}
+	if v := os.Getenv("LOGIN_LOCKOUT_MINUTES"); v != "" {
+		minutes, err := strconv.Atoi(v)
+		if err != nil || minutes <= 0 {
+			log.Fatalf("invalid LOGIN_LOCKOUT_MINUTES %q", v)
+		}
+		accountPolicy.Lockout = time.Duration(minutes) * time.Minute
+		ipPolicy.Lockout = accountPolicy.Lockout
+	}
+	loginThrottleRepo, err := repository.NewLoginThrottleRepo(ctx, client, dbName)
+	if err != nil {
+		log.Fatalf("failed to initialise login throttle repository: %v", err)
+	}
+	loginGuard := service.NewLoginGuard(loginThrottleRepo, userRepo, auditSvc, accountPolicy, ipPolicy)
+
+	// За обратным прокси (на Render — всегда) все запросы приходят с его адреса;
+	// адрес клиента берётся из X-Forwarded-For, только если прокси указан в
+	// TRUSTED_PROXIES (адреса и подсети через запятую).
+	proxies, err := handler.ParseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		log.Fatalf("invalid TRUSTED_PROXIES: %v", err)
+	}
+	authHandler := handler.NewAuthHandler(userSvc, tokenSvc, accountSvc, loginGuard, proxies, jwtSecret)
+	userAdminSvc := service.NewUserAdminService(userRepo, roleSvc, tokenSvc, auditSvc)
+	userHandler := handler.NewUserHandler(userAdminSvc)
 
 	repo, err := repository.NewMovieRepo(ctx, client, dbName)
 	if err != nil {
 		log.Fatalf("failed to initialise movie repository: %v", err)
 	}
 
+	// Справочник жанров. Фильмы, где жанр ещё записан строкой, переводятся на id.
+	genreRepo, err := repository.NewGenreRepo(ctx, client, dbName)
+	if err != nil {
+		log.Fatalf("failed to initialise genre repository: %v", err)
+	}
+	genreSvc := service.NewGenreService(genreRepo, repo)
+	if n, err := genreSvc.MigrateLegacy(ctx); err != nil {
+		log.Fatalf("failed to migrate movie genres: %v", err)
+	} else if n > 0 {
+		log.Printf("migrated genres of %d movies to the genre catalog", n)
+	}
+	genreHandler := handler.NewGenreHandler(genreSvc)
+
+	// Актёры и съёмочная группа; титры хранятся в фильмах.
+	personRepo, err := repository.NewPersonRepo(ctx, client, dbName)
+	if err != nil {
+		log.Fatalf("failed to initialise person repository: %v", err)
+	}
+	personSvc := service.NewPersonService(personRepo, repo)
+	personHandler := handler.NewPersonHandler(personSvc)
+
+	// Часовой пояс кинотеатра: в нём считаются «сегодня» и фильтр сеансов по дате.
+	tzName := os.Getenv("CINEMA_TZ")
+	if tzName == "" {
+		tzName = "Asia/Almaty"
+	}
+	loc, err := time.LoadLocation(tzName)
+	if err != nil {
+		log.Fatalf("invalid CINEMA_TZ %q: %v", tzName, err)
+	}
+
+	// Залы, схемы мест и сеансы.
+	hallRepo, err := repository.NewHallRepo(ctx, client, dbName)
+	if err != nil {
+		log.Fatalf("failed to initialise hall repository: %v", err)
+	}
+	sessionRepo, err := repository.NewSessionRepo(ctx, client, dbName)
+	if err != nil {
+		log.Fatalf("failed to initialise session repository: %v", err)
+	}
 	// Repository → Service → Handler (Assignment 3 architecture)
-	svc := service.NewMovieService(repo)
+	svc := service.NewMovieService(repo, sessionRepo, genreSvc, personSvc)
 	movieHandler := handler.NewMovieHandler(svc)
+	// Фильмы, у которых наступила дата премьеры или закончился прокат,
+	// переходят в следующий статус; дальше это делает фоновая задача.
+	if n, err := svc.AdvanceStatuses(ctx); err != nil {
+		log.Fatalf("failed to update movie statuses: %v", err)
+	} else if n > 0 {
+		log.Printf("updated the status of %d movies", n)
+	}
+
+	// Бронирования и билеты.
+	bookingRepo, err := repository.NewBookingRepo(ctx, client, dbName)
+	if err != nil {
+		log.Fatalf("failed to initialise booking repository: %v", err)
+	}
+
+	hallSvc := service.NewHallService(hallRepo, sessionRepo)
+	hallHandler := handler.NewHallHandler(hallSvc)
+	// Перерыв на уборку зала между сеансами (SESSION_CLEANING_BUFFER, минуты).
+	cleaningBuffer := 15 * time.Minute
+	if v := os.Getenv("SESSION_CLEANING_BUFFER"); v != "" {
+		minutes, err := strconv.Atoi(v)
+		if err != nil || minutes < 0 {
+			log.Fatalf("invalid SESSION_CLEANING_BUFFER %q", v)
+		}
+		cleaningBuffer = time.Duration(minutes) * time.Minute
+	}
+	sessionSvc := service.NewSessionService(sessionRepo, repo, hallRepo, bookingRepo, loc, cleaningBuffer)
+	sessionHandler := handler.NewSessionHandler(sessionSvc)
+
+	// Демо-расписание на ближайшие дни (DEMO_SCHEDULE_DAYS, по умолчанию 7; 0 — отключить).
+	demoDays := 7
+	if v := os.Getenv("DEMO_SCHEDULE_DAYS"); v != "" {
+		if demoDays, err = strconv.Atoi(v); err != nil {
+			log.Fatalf("invalid DEMO_SCHEDULE_DAYS %q: %v", v, err)
+		}
+	}
+	if err := sessionSvc.SeedDemoSchedule(ctx, demoDays); err != nil {
+		log.Fatalf("failed to seed demo schedule: %v", err)
+	}
+
+	// Цены билетов считаются на сервере по правилам из коллекции pricing_rules.
+	pricingRepo, err := repository.NewPricingRepo(ctx, client, dbName)
+	if err != nil {
+		log.Fatalf("failed to initialise pricing repository: %v", err)
+	}
+	pricingSvc := service.NewPricingService(pricingRepo, sessionRepo, hallRepo, loc)
+	pricingHandler := handler.NewPricingHandler(pricingSvc)
+
+	// Изменения мест рассылаются подписчикам SSE через брокер в памяти процесса.
+	seatEvents := realtime.NewHub()
+
+	// Электронные билеты подписываются отдельным ключом TICKET_SIGNING_KEY, не JWT_SECRET.
+	ticketKey := os.Getenv("TICKET_SIGNING_KEY")
+	if ticketKey == jwtSecret {
+		log.Fatal("TICKET_SIGNING_KEY must differ from JWT_SECRET")
+	}
+	if ticketKey == "" {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			log.Fatalf("failed to generate ticket signing key: %v", err)
+		}
+		ticketKey = string(key)
+		log.Println("TICKET_SIGNING_KEY is not set; using a random key, issued QR codes stop working after restart")
+	}
+	ticketSigner := eticket.NewSigner([]byte(ticketKey))
+
+	bookingSvc := service.NewBookingService(bookingRepo, sessionRepo, repo, hallRepo, pricingSvc, seatEvents, ticketSigner)
+	bookingHandler := handler.NewBookingHandler(bookingSvc)
+
+	// Временные удержания мест (HOLD_TTL_MINUTES, по умолчанию 10 минут).
+	holdTTL := 10 * time.Minute
+	if v := os.Getenv("HOLD_TTL_MINUTES"); v != "" {
+		minutes, err := strconv.Atoi(v)
+		if err != nil || minutes <= 0 {
+			log.Fatalf("invalid HOLD_TTL_MINUTES %q", v)
+		}
+		holdTTL = time.Duration(minutes) * time.Minute
+	}
+	holdRepo, err := repository.NewHoldRepo(ctx, client, dbName)
+	if err != nil {
+		log.Fatalf("failed to initialise hold repository: %v", err)
+	}
+	holdSvc := service.NewHoldService(holdRepo, sessionRepo, hallRepo, bookingSvc, seatEvents, holdTTL)
+	holdHandler := handler.NewHoldHandler(holdSvc)
+
+	// Оплата через платёжного провайдера (пока только встроенный тестовый).
+	// Ключ подписи вебхуков — PAYMENT_WEBHOOK_SECRET; без него генерируется случайный.
+	webhookSecret := []byte(os.Getenv("PAYMENT_WEBHOOK_SECRET"))
+	if len(webhookSecret) == 0 {
+		webhookSecret = make([]byte, 32)
+		if _, err := rand.Read(webhookSecret); err != nil {
+			log.Fatalf("failed to generate webhook secret: %v", err)
+		}
+		log.Println("PAYMENT_WEBHOOK_SECRET is not set; using a random secret for this run")
+	}
+	webhookURL := os.Getenv("PAYMENT_WEBHOOK_URL")
+	if webhookURL == "" {
+		webhookURL = "http://localhost" + port + "/api/payments/webhook"
+	}
+	fakeDelay := 2 * time.Second
+	if v := os.Getenv("PAYMENT_FAKE_DELAY"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds < 0 {
+			log.Fatalf("invalid PAYMENT_FAKE_DELAY %q", v)
+		}
+		fakeDelay = time.Duration(seconds) * time.Second
+	}
+	fakeOutcome := payment.Outcome(os.Getenv("PAYMENT_FAKE_OUTCOME"))
+	if fakeOutcome != "" && !payment.ValidOutcome(fakeOutcome) {
+		log.Fatalf("invalid PAYMENT_FAKE_OUTCOME %q", fakeOutcome)
+	}
+	// Сколько бронирование ждёт оплаты (PAYMENT_TIMEOUT_MINUTES, по умолчанию 15).
+	paymentTimeout := 15 * time.Minute
+	if v := os.Getenv("PAYMENT_TIMEOUT_MINUTES"); v != "" {
+		minutes, err := strconv.Atoi(v)
+		if err != nil || minutes <= 0 {
+			log.Fatalf("invalid PAYMENT_TIMEOUT_MINUTES %q", v)
+		}
+		paymentTimeout = time.Duration(minutes) * time.Minute
+	}
+	paymentRepo, err := repository.NewPaymentRepo(ctx, client, dbName)
+	if err != nil {
+		log.Fatalf("failed to initialise payment repository: %v", err)
+	}
+	refundRepo, err := repository.NewRefundRepo(ctx, client, dbName)
+	if err != nil {
+		log.Fatalf("failed to initialise refund repository: %v", err)
+	}
+	paymentProvider := payment.NewFakeProvider(webhookURL, webhookSecret, fakeDelay, fakeOutcome)
+	paymentSvc := service.NewPaymentService(paymentRepo, refundRepo, bookingRepo, paymentProvider, seatEvents, webhookSecret, paymentTimeout)
+	paymentHandler := handler.NewPaymentHandler(paymentSvc)
+
+	// Проход по билетам открывается за CHECKIN_OPENS_MINUTES (по умолчанию 30) до начала сеанса.
+	checkinOpens := 30 * time.Minute
+	if v := os.Getenv("CHECKIN_OPENS_MINUTES"); v != "" {
+		minutes, err := strconv.Atoi(v)
+		if err != nil || minutes < 0 {
+			log.Fatalf("invalid CHECKIN_OPENS_MINUTES %q", v)
+		}
+		checkinOpens = time.Duration(minutes) * time.Minute
+	}
+	ticketSvc := service.NewTicketService(bookingRepo, sessionRepo, repo, hallRepo, ticketSigner, loc, checkinOpens)
+	ticketHandler := handler.NewTicketHandler(ticketSvc)
+
+	// Личный кабинет.
+	profileSvc := service.NewProfileService(userRepo, userTokenRepo, bookingRepo, sessionRepo, repo, hallRepo, accountSvc, tokenSvc, auditSvc, ticketSigner)
+	meHandler := handler.NewMeHandler(profileSvc)
+
+	// Отмена бронирований. Политика возврата: полностью не позже чем за
+	// REFUND_FULL_HOURS (2) часа до сеанса, затем REFUND_LATE_PERCENT (50) процентов.
+	refundPolicy := service.DefaultRefundPolicy
+	if v := os.Getenv("REFUND_FULL_HOURS"); v != "" {
+		hours, err := strconv.Atoi(v)
+		if err != nil || hours < 0 {
+			log.Fatalf("invalid REFUND_FULL_HOURS %q", v)
+		}
+		refundPolicy.FullBefore = time.Duration(hours) * time.Hour
+	}
+	if v := os.Getenv("REFUND_LATE_PERCENT"); v != "" {
+		percent, err := strconv.Atoi(v)
+		if err != nil || percent < 0 || percent > 100 {
+			log.Fatalf("invalid REFUND_LATE_PERCENT %q", v)
+		}
+		refundPolicy.LatePercent = percent
+	}
+	cancellationSvc := service.NewCancellationService(bookingRepo, sessionRepo, paymentSvc, auditSvc, seatEvents, refundPolicy)
+	cancellationHandler := handler.NewCancellationHandler(cancellationSvc)
+
+	// Схема мест сеанса с состояниями.
+	seatSvc := service.NewSeatService(sessionRepo, hallRepo, bookingRepo, holdRepo)
+	seatHandler := handler.NewSeatHandler(seatSvc, seatEvents)
 
 	// Simple HTML homepage so root "/" is not empty.
 	http.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
@@ -432,6 +780,11 @@ func main() {
       font-size: 8px;
       color: #e5e7eb;
     }
+    .seat.vip { border-color: rgba(251, 191, 36, 0.8); }
+    .seat.loveseat { border-color: rgba(236, 72, 153, 0.8); }
+    .seat.accessible { border-color: rgba(14, 165, 233, 0.9); }
+    .seat-aisle { width: 12px; }
+    .seat-gap { width: 18px; }
 
     .seat-legend {
       display: flex;
@@ -605,7 +958,13 @@ func main() {
                 <div class="legend-swatch selected"></div><span>Выбрано</span>
               </div>
               <div class="legend-item">
-                <div class="legend-swatch unavailable"></div><span>Занято (для демо не используется)</span>
+                <div class="legend-swatch unavailable"></div><span>Занято</span>
+              </div>
+              <div class="legend-item">
+                <div class="legend-swatch free" style="border-color:rgba(251,191,36,0.8)"></div><span>VIP</span>
+              </div>
+              <div class="legend-item">
+                <div class="legend-swatch free" style="border-color:rgba(14,165,233,0.9)"></div><span>Для маломобильных</span>
               </div>
             </div>
           </div>
@@ -624,7 +983,7 @@ func main() {
             </div>
             <div id="tickets-list" class="summary-list"></div>
             <div class="summary-row">
-              <button id="btn-book" disabled>Забронировать (демо)</button>
+              <button id="btn-book" disabled>Забронировать</button>
               <button id="btn-clear" class="btn-outline" type="button">Сбросить выбор</button>
             </div>
           </div>
@@ -634,7 +993,7 @@ func main() {
   </div>
 
   <footer>
-    <span>Demo UI · Нет реальной оплаты, только учебный выбор билетов.</span>
+    <span>Demo UI · Оплата через тестовый провайдер, реальные деньги не списываются.</span>
     <span>Team: Alkhan Almas &amp; Nurbauli Turar</span>
   </footer>
 
@@ -652,44 +1011,90 @@ func main() {
     const btnBook = document.getElementById("btn-book");
     const btnClear = document.getElementById("btn-clear");
 
+    // Цены считает сервер (/api/sessions/:id/prices); здесь только подписи.
     const ticketTypes = {
-      adult:  { label: "Взрослый", price: 2500 },
-      student:{ label: "Студент", price: 1900 },
-      child:  { label: "Детский", price: 1600 }
+      adult:  { label: "Взрослый" },
+      student:{ label: "Студент" },
+      child:  { label: "Детский" }
     };
+    let priceList = null; // категория места -> тип билета -> цена
+
+    async function loadPrices(sessionId) {
+      priceList = null;
+      try {
+        const res = await fetch("/api/sessions/" + sessionId + "/prices");
+        if (!res.ok) throw new Error("HTTP " + res.status);
+        const data = await res.json();
+        if (selectedShow && selectedShow.id === sessionId) {
+          priceList = data.prices;
+          updateSummary();
+        }
+      } catch (e) {
+        priceList = null;
+      }
+    }
+
+    function ticketPrice(ticket) {
+      const byType = priceList && priceList[ticket.category];
+      return byType ? byType[ticket.type] : null;
+    }
 
-    const seatConfig = { rows: 8, cols: 12 };
+    let halls = [];
     let movies = [];
+    let genreNames = {};
     let selectedMovie = null;
-    let selectedShow = null; // { hall, time }
+    let selectedShow = null; // сеанс из /api/sessions
     const selectedSeats = new Map(); // key -> { rowLabel, seatNumber, type }
 
-    function rowLabel(index) {
-      return String.fromCharCode("A".charCodeAt(0) + index);
+    async function loadHalls() {
+      try {
+        const res = await fetch("/api/halls");
+        if (!res.ok) throw new Error("HTTP " + res.status);
+        halls = await res.json();
+      } catch (e) {
+        halls = [];
+      }
     }
 
-    function generateScheduleForMovie(movie, index) {
-      // Просто детерминированный "рандом": 3 сеанса, залы 1–8
-      const baseHour = 11 + (index % 3) * 3;
-      const hall1 = (index % 8) + 1;
-      const hall2 = ((index + 3) % 8) + 1;
-      const hall3 = ((index + 5) % 8) + 1;
-      const time1 = String(baseHour).padStart(2,"0") + ":00";
-      const time2 = String(baseHour+3).padStart(2,"0") + ":30";
-      const time3 = String(baseHour+6).padStart(2,"0") + ":15";
-      return [
-        { hall: hall1, time: time1 },
-        { hall: hall2, time: time2 },
-        { hall: hall3, time: time3 },
-      ];
+    function hallById(id) {
+      return halls.find(h => h.id === id) || null;
+    }
+
+    function hallName(id) {
+      const hall = hallById(id);
+      return hall ? hall.name : "Зал " + id;
+    }
+
+    function localDate(d) {
+      return d.getFullYear() + "-" + String(d.getMonth() + 1).padStart(2, "0") + "-" + String(d.getDate()).padStart(2, "0");
+    }
+
+    function showTime(s) {
+      const d = new Date(s.startTime);
+      return String(d.getHours()).padStart(2, "0") + ":" + String(d.getMinutes()).padStart(2, "0");
+    }
+
+    async function loadSchedule(movie) {
+      const params = new URLSearchParams({ movieId: movie.id, date: localDate(new Date()) });
+      const res = await fetch("/api/sessions?" + params);
+      if (!res.ok) throw new Error("HTTP " + res.status);
+      const now = Date.now();
+      return (await res.json()).filter(s => new Date(s.startTime).getTime() > now);
     }
 
     async function loadMovies() {
       moviesEl.innerHTML = "<p style='color:var(--muted);font-size:13px;'>Загружаем афишу...</p>";
       try {
-        const res = await fetch("/api/movies");
+        const [res, genresRes] = await Promise.all([
+          fetch("/api/movies?status=now_showing,presale&sort=rating&order=desc&limit=100"),
+          fetch("/api/genres"),
+        ]);
         if (!res.ok) throw new Error("HTTP " + res.status);
-        const data = await res.json();
+        if (genresRes.ok) {
+          genreNames = {};
+          (await genresRes.json()).forEach(g => { genreNames[g.id] = g.name.ru; });
+        }
+        const data = (await res.json()).items;
         if (!Array.isArray(data) || data.length === 0) {
           moviesEl.innerHTML = "<p style='color:var(--muted);font-size:13px;'>Фильмы пока не добавлены.</p>";
           return;
@@ -701,6 +1106,14 @@ func main() {
       }
     }
 
+    function ageRestricted(m) {
+      return !!m && (m.ageRating === "16+" || m.ageRating === "18+");
+    }
+
+    function genreText(m) {
+      return (m.genres || []).map(id => genreNames[id]).filter(Boolean).join(", ");
+    }
+
     function renderMovies() {
         moviesEl.innerHTML = "";
       movies.forEach((m, index) => {
@@ -720,9 +1133,10 @@ func main() {
         const meta = document.createElement("div");
         meta.className = "movie-meta";
         meta.textContent =
-          (m.genre || "Жанр не указан") +
+          (genreText(m) || "Жанр не указан") +
           " · " +
-          (m.duration ? (m.duration + " мин") : "длительность неизвестна");
+          (m.duration ? (m.duration + " мин") : "длительность неизвестна") +
+          (m.ageRating ? " · " + m.ageRating : "");
 
         const tags = document.createElement("div");
         tags.className = "movie-tags";
@@ -758,7 +1172,7 @@ func main() {
       });
     }
 
-    function selectMovie(movie, index, cardEl) {
+    async function selectMovie(movie, index, cardEl) {
       selectedMovie = movie;
       selectedShow = null;
       selectedSeats.clear();
@@ -773,23 +1187,35 @@ func main() {
 
       bookingTitle.textContent = movie.title || "Выбранный фильм";
       bookingSubtitle.textContent =
-        (movie.genre || "Жанр не указан") +
+        (genreText(movie) || "Жанр не указан") +
         " · " +
         (movie.duration ? (movie.duration + " мин") : "длительность неизвестна") +
+        (movie.ageRating ? " · " + movie.ageRating : "") +
         (movie.rating ? " · Рейтинг: " + movie.rating : "");
 
-      const schedule = generateScheduleForMovie(movie, index);
+      let schedule = [];
+      try {
+        schedule = await loadSchedule(movie);
+      } catch (e) {
+        scheduleRowEl.innerHTML = "<span style='color:var(--muted);font-size:12px;'>Не удалось загрузить расписание.</span>";
+        return;
+      }
+      if (selectedMovie !== movie) return; // пока грузили, выбрали другой фильм
+      if (schedule.length === 0) {
+        scheduleRowEl.innerHTML = "<span style='color:var(--muted);font-size:12px;'>На сегодня сеансов больше нет.</span>";
+        return;
+      }
       schedule.forEach((s, i) => {
         const chip = document.createElement("button");
         chip.type = "button";
         chip.className = "chip";
-        chip.innerHTML = "<span>" + s.time + " · Зал " + s.hall + "</span>";
+        chip.innerHTML = "<span>" + showTime(s) + " · " + hallName(s.hallId) + "</span>";
         chip.addEventListener("click", () => {
           document.querySelectorAll(".chip").forEach(c => c.classList.remove("active"));
           chip.classList.add("active");
           selectedShow = s;
-          hallLabelEl.textContent = "Зал " + s.hall;
-          timeLabelEl.textContent = "Сегодня, " + s.time;
+          hallLabelEl.textContent = hallName(s.hallId);
+          timeLabelEl.textContent = "Сегодня, " + showTime(s) + (s.format ? " · " + s.format : "");
           selectedSeats.clear();
           renderSeatGrid();
           updateSummary();
@@ -802,38 +1228,120 @@ func main() {
       });
     }
 
+    // Схема мест выбранного сеанса из /api/sessions/:id/seats и её ETag.
+    let seatMap = null;
+    let seatMapEtag = null;
+
     function renderSeatGrid() {
-      seatGridEl.innerHTML = "";
+      seatMap = null;
+      seatMapEtag = null;
       if (!selectedShow) {
+        if (seatStream) seatStream.close();
+        seatStream = null;
         seatGridEl.innerHTML = "<p style='color:var(--muted);font-size:12px;text-align:center;margin-top:12px;'>Сначала выберите время и зал.</p>";
-                return;
-              }
+        return;
+      }
+      seatGridEl.innerHTML = "<p style='color:var(--muted);font-size:12px;text-align:center;margin-top:12px;'>Загружаем схему зала...</p>";
+      subscribeSeats(selectedShow.id);
+      loadPrices(selectedShow.id);
+      refreshSeatMap().catch(() => {
+        seatGridEl.innerHTML = "<p style='color:var(--muted);font-size:12px;text-align:center;margin-top:12px;'>Схема зала недоступна.</p>";
+      });
+    }
+
+    // Перезапрашивает схему; если ничего не изменилось, сервер отвечает 304.
+    async function refreshSeatMap() {
+      if (!selectedShow) return;
+      const sessionId = selectedShow.id;
+      const headers = seatMapEtag ? { "If-None-Match": seatMapEtag } : {};
+      const res = await fetch("/api/sessions/" + sessionId + "/seats", { headers });
+      if (!selectedShow || selectedShow.id !== sessionId || res.status === 304) return;
+      if (!res.ok) throw new Error("HTTP " + res.status);
+      seatMapEtag = res.headers.get("ETag");
+      seatMap = await res.json();
+      drawSeatGrid();
+    }
+
+    function drawSeatGrid() {
+      seatGridEl.innerHTML = "";
+      let selectionChanged = false;
       const frag = document.createDocumentFragment();
-      for (let r = 0; r < seatConfig.rows; r++) {
+      seatMap.rows.forEach(r => {
         const row = document.createElement("div");
         row.className = "seat-row";
         const label = document.createElement("div");
         label.className = "seat-row-label";
-        const rLabel = rowLabel(r);
+        const rLabel = r.label;
         label.textContent = rLabel;
         row.appendChild(label);
 
-        for (let c = 1; c <= seatConfig.cols; c++) {
+        r.seats.forEach(cell => {
+          if (cell.kind !== "seat") {
+            const spacer = document.createElement("div");
+            spacer.className = "seat-" + cell.kind;
+            row.appendChild(spacer);
+            return;
+          }
+          const c = cell.number;
           const key = rLabel + c;
           const seat = document.createElement("button");
           seat.type = "button";
-          seat.className = "seat";
+          seat.className = "seat " + (cell.category || "standard");
           seat.dataset.key = key;
+          seat.title = "Ряд " + rLabel + ", место " + c;
           seat.innerHTML = "<span></span>";
-          seat.addEventListener("click", () => toggleSeat(key, rLabel, c, seat));
+          const available = cell.state === "free" || cell.state === "accessible";
+          if (!available) {
+            seat.classList.add("unavailable");
+            seat.disabled = true;
+            // Место, которое мы выбрали, успел занять кто-то другой.
+            if (selectedSeats.delete(key)) selectionChanged = true;
+          } else {
+            if (selectedSeats.has(key)) seat.classList.add("selected");
+            seat.addEventListener("click", () => toggleSeat(key, rLabel, c, cell.category, seat));
+          }
           row.appendChild(seat);
-        }
+        });
         frag.appendChild(row);
-      }
+      });
       seatGridEl.appendChild(frag);
+      if (selectionChanged) updateSummary();
+    }
+
+    // Живые обновления схемы через SSE; опрос с ETag остаётся запасным вариантом.
+    let seatStream = null;
+
+    function subscribeSeats(sessionId) {
+      if (seatStream) seatStream.close();
+      seatStream = null;
+      if (!window.EventSource) return;
+      seatStream = new EventSource("/api/sessions/" + sessionId + "/seats/stream");
+      seatStream.addEventListener("snapshot", e => {
+        if (!selectedShow || selectedShow.id !== sessionId) return;
+        seatMap = JSON.parse(e.data);
+        seatMapEtag = null;
+        drawSeatGrid();
+      });
+      seatStream.addEventListener("seats", e => {
+        const ev = JSON.parse(e.data);
+        if (!seatMap || !selectedShow || selectedShow.id !== ev.sessionId) return;
+        const changed = new Set(ev.seats.map(s => s.row + s.seat));
+        seatMap.rows.forEach(r => r.seats.forEach(cell => {
+          if (cell.kind !== "seat" || !changed.has(r.label + cell.number)) return;
+          if (ev.state === "free") {
+            cell.state = cell.blocked ? "blocked" : (cell.category === "accessible" ? "accessible" : "free");
+          } else {
+            cell.state = ev.state;
+          }
+        }));
+        seatMapEtag = null;
+        drawSeatGrid();
+      });
     }
 
-    function toggleSeat(key, rowLabelValue, seatNumber, seatEl) {
+    setInterval(() => { refreshSeatMap().catch(() => {}); }, 30000);
+
+    function toggleSeat(key, rowLabelValue, seatNumber, category, seatEl) {
       if (!selectedShow) return;
       if (selectedSeats.has(key)) {
         selectedSeats.delete(key);
@@ -842,6 +1350,7 @@ func main() {
         selectedSeats.set(key, {
           rowLabel: rowLabelValue,
           seatNumber: seatNumber,
+          category: category || "standard",
           type: "adult"
         });
         seatEl.classList.add("selected");
@@ -858,7 +1367,7 @@ func main() {
         return;
       }
       summaryCaptionEl.textContent =
-        "Зал " + selectedShow.hall + ", " + selectedShow.time + " · " + selectedSeats.size + " мест(а)";
+        hallName(selectedShow.hallId) + ", " + showTime(selectedShow) + " · " + selectedSeats.size + " мест(а)";
 
       let total = 0;
       selectedSeats.forEach((ticket, key) => {
@@ -872,6 +1381,8 @@ func main() {
         const typeSel = document.createElement("select");
         typeSel.className = "ticket-type";
         for (const [code, t] of Object.entries(ticketTypes)) {
+          // На фильмы 16+/18+ детские билеты не продаются.
+          if (code === "child" && ageRestricted(selectedMovie)) continue;
           const opt = document.createElement("option");
           opt.value = code;
           opt.textContent = t.label;
@@ -897,12 +1408,11 @@ func main() {
       function renderSummary() {
         let sum = 0;
         selectedSeats.forEach((ticket, key) => {
-          const type = ticketTypes[ticket.type] || ticketTypes.adult;
-          const itemPrice = type.price;
-          sum += itemPrice;
+          const itemPrice = ticketPrice(ticket);
+          sum += itemPrice || 0;
           const priceEl = ticketsListEl.querySelector('.ticket-price[data-key="' + key + '"]');
           if (priceEl) {
-            priceEl.textContent = itemPrice.toLocaleString("ru-RU") + " ₸";
+            priceEl.textContent = itemPrice == null ? "…" : itemPrice.toLocaleString("ru-RU") + " ₸";
           }
         });
         total = sum;
@@ -918,28 +1428,310 @@ func main() {
       updateSummary();
     });
 
-    btnBook.addEventListener("click", () => {
+    // Токены хранятся в localStorage; если их нет или они отозваны, спрашиваем email и пароль.
+    function saveTokens(data) {
+      localStorage.setItem("token", data.token);
+      localStorage.setItem("tokenExpiresAt", data.expiresAt);
+      localStorage.setItem("refreshToken", data.refreshToken);
+    }
+
+    function clearTokens() {
+      ["token", "tokenExpiresAt", "refreshToken"].forEach(k => localStorage.removeItem(k));
+    }
+
+    // Access-токен живёт недолго: за минуту до истечения меняем его по refresh-токену.
+    async function ensureToken() {
+      const token = localStorage.getItem("token");
+      const expiresAt = Date.parse(localStorage.getItem("tokenExpiresAt") || "");
+      if (token && expiresAt - Date.now() > 60 * 1000) return token;
+      const refreshToken = localStorage.getItem("refreshToken");
+      if (refreshToken) {
+        const res = await fetch("/api/auth/refresh", {
+          method: "POST",
+          headers: { "Content-Type": "application/json" },
+          body: JSON.stringify({ refreshToken })
+        });
+        if (res.ok) {
+          const data = await res.json();
+          saveTokens(data);
+          return data.token;
+        }
+        clearTokens();
+      }
+      return login();
+    }
+
+    async function login() {
+      const email = prompt("Войдите, чтобы забронировать билеты.\nEmail:");
+      if (!email) return null;
+      const password = prompt("Пароль:");
+      if (!password) return null;
+      const res = await fetch("/api/auth/login", {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify({ email, password })
+      });
+      if (res.status === 429) {
+        alert("Слишком много неудачных попыток. Попробуйте снова через " + res.headers.get("Retry-After") + " с.");
+        return null;
+      }
+      if (res.status === 403) {
+        const err = await res.json().catch(() => ({}));
+        if (!err.passwordChangeRequired) {
+          alert("Вход запрещён: " + (err.error || res.status));
+          return null;
+        }
+        return changePassword(email, password);
+      }
+      if (!res.ok) {
+        if (confirm("Неверный email или пароль.\nОтправить на " + email + " ссылку для сброса пароля?")) {
+          await fetch("/api/auth/forgot", {
+            method: "POST",
+            headers: { "Content-Type": "application/json" },
+            body: JSON.stringify({ email })
+          });
+          alert("Если такой пользователь есть, письмо со ссылкой уже отправлено.");
+        }
+        return null;
+      }
+      return finishLogin(await res.json());
+    }
+
+    // Если включён второй фактор, спрашиваем код из приложения-аутентификатора.
+    async function finishLogin(data) {
+      if (data.mfaRequired) {
+        let question = "Код из приложения-аутентификатора (или код восстановления):";
+        if (data.enrollment) {
+          question = "Для вашей роли обязательна двухфакторная аутентификация.\n" +
+            "Добавьте в приложение-аутентификатор ключ " + data.enrollment.secret + "\nи введите код из него:";
+        }
+        const code = prompt(question);
+        if (!code) return null;
+        const res = await fetch("/api/auth/login/2fa", {
+          method: "POST",
+          headers: { "Content-Type": "application/json" },
+          body: JSON.stringify({ mfaToken: data.mfaToken, code })
+        });
+        data = await res.json().catch(() => ({}));
+        if (!res.ok) {
+          alert("Вход не выполнен: " + (data.error || res.status));
+          return null;
+        }
+        if (data.recoveryCodes) {
+          alert("Сохраните коды восстановления — они показываются один раз:\n" + data.recoveryCodes.join("\n"));
+        }
+      }
+      saveTokens(data);
+      return data.token;
+    }
+
+    // Временный пароль (выданный администратором или из конфигурации) нужно сменить до входа.
+    async function changePassword(email, password) {
+      const newPassword = prompt("Пароль временный, придумайте новый (не короче 8 символов):");
+      if (!newPassword) return null;
+      const send = code => fetch("/api/auth/change-password", {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify({ email, password, newPassword, code })
+      });
+      let res = await send("");
+      let data = await res.json().catch(() => ({}));
+      if (data.codeRequired) {
+        const code = prompt("Код из приложения-аутентификатора или код восстановления:");
+        if (!code) return null;
+        res = await send(code);
+        data = await res.json().catch(() => ({}));
+      }
+      if (!res.ok) {
+        alert("Не удалось сменить пароль: " + (data.error || res.status));
+        return null;
+      }
+      return finishLogin(data);
+    }
+
+    // Ждём, пока вебхук провайдера переведёт бронь в paid или failed.
+    async function waitForPayment(bookingId, headers) {
+      let booking = {};
+      for (let i = 0; i < 20; i++) {
+        await new Promise(resolve => setTimeout(resolve, 1000));
+        const res = await fetch("/api/bookings/" + bookingId, { headers });
+        if (!res.ok) continue;
+        booking = await res.json();
+        if (booking.status !== "pending") break;
+      }
+      return booking;
+    }
+
+    btnBook.addEventListener("click", async () => {
       if (!selectedMovie || !selectedShow || selectedSeats.size === 0) return;
-      alert("Демо: бронь создана.\n\nФильм: " + selectedMovie.title +
-        "\nЗал: " + selectedShow.hall +
-        "\nВремя: " + selectedShow.time +
-        "\nМест: " + selectedSeats.size +
-        "\nСумма: " + totalPriceEl.textContent +
-        "\n\n(В учебной версии данные не сохраняются в базе.)");
+      const token = await ensureToken();
+      if (!token) return;
+
+      const tickets = [];
+      selectedSeats.forEach(t => tickets.push({ row: t.rowLabel, seat: t.seatNumber, type: t.type }));
+      const headers = { "Content-Type": "application/json", "Authorization": "Bearer " + token };
+      btnBook.disabled = true;
+      try {
+        // Сначала удерживаем места, затем подтверждаем заказ.
+        let res = await fetch("/api/sessions/" + selectedShow.id + "/holds", {
+          method: "POST",
+          headers,
+          body: JSON.stringify({ seats: tickets.map(t => ({ row: t.row, seat: t.seat })) })
+        });
+        let data = await res.json().catch(() => ({}));
+        if (res.status === 401) {
+          clearTokens();
+          alert("Сессия истекла, войдите снова.");
+          return;
+        }
+        if (res.status === 409) {
+          const taken = (data.conflict || []).map(s => s.row + s.seat).join(", ");
+          alert("Эти места уже заняты: " + (taken || "часть выбранных мест") + ". Выберите другие.");
+          return;
+        }
+        if (!res.ok) {
+          alert("Не удалось удержать места: " + (data.error || res.status));
+          return;
+        }
+        const hold = data;
+        const until = new Date(hold.expiresAt).toLocaleTimeString("ru-RU", { hour: "2-digit", minute: "2-digit" });
+        if (!confirm("Места удержаны до " + until + ".\nСумма: " + totalPriceEl.textContent + "\n\nПодтвердить бронь?")) {
+          await fetch("/api/holds/" + hold.id, { method: "DELETE", headers });
+          return;
+        }
+
+        res = await fetch("/api/holds/" + hold.id + "/booking", {
+          method: "POST",
+          headers,
+          body: JSON.stringify({ tickets })
+        });
+        data = await res.json().catch(() => ({}));
+        if (res.status === 409 && data.conflict && data.conflict.ageVerificationRequired) {
+          // Продажа кассиром на фильм 16+/18+: сначала проверить документ покупателя.
+          if (!confirm("Фильм " + data.conflict.ageRating + ". Проверьте документ покупателя.\n\nВозраст подтверждён?")) {
+            await fetch("/api/holds/" + hold.id, { method: "DELETE", headers });
+            return;
+          }
+          res = await fetch("/api/holds/" + hold.id + "/booking", {
+            method: "POST",
+            headers,
+            body: JSON.stringify({ tickets, ageConfirmed: true })
+          });
+          data = await res.json().catch(() => ({}));
+        }
+        if (!res.ok) {
+          alert("Не удалось оформить бронь: " + (data.error || res.status));
+          return;
+        }
+        const booking = data;
+        selectedSeats.clear();
+        renderSeatGrid();
+        updateSummary();
+
+        // Оплата: тестовый провайдер подтверждает платёж вебхуком через пару секунд.
+        const card = prompt("Бронь №" + booking.id + " на сумму " + booking.total.toLocaleString("ru-RU") +
+          " ₸.\nВведите номер карты для оплаты:", "4242 4242 4242 4242");
+        if (card === null) {
+          alert("Бронь №" + booking.id + " ожидает оплаты. Неоплаченная бронь будет отменена автоматически.");
+          return;
+        }
+        res = await fetch("/api/bookings/" + booking.id + "/payments", {
+          method: "POST",
+          headers,
+          body: JSON.stringify({ card: card.replace(/\s+/g, "") })
+        });
+        data = await res.json().catch(() => ({}));
+        if (!res.ok) {
+          alert("Не удалось начать оплату: " + (data.error || res.status));
+          return;
+        }
+        const paid = await waitForPayment(booking.id, headers);
+        if (paid.status === "paid") {
+          const openPdf = confirm("Бронь №" + paid.id + " оплачена.\n\nФильм: " + selectedMovie.title +
+            "\nЗал: " + hallName(selectedShow.hallId) +
+            "\nВремя: " + showTime(selectedShow) +
+            "\nМест: " + paid.tickets.length +
+            "\nСумма: " + paid.total.toLocaleString("ru-RU") + " ₸" +
+            "\n\nОткрыть билеты в PDF?");
+          if (openPdf) {
+            const pdf = await fetch("/api/bookings/" + paid.id + "/ticket.pdf", { headers });
+            if (pdf.ok) window.open(URL.createObjectURL(await pdf.blob()), "_blank");
+          }
+        } else if (paid.status === "failed") {
+          alert("Оплата отклонена, места освобождены.");
+        } else {
+          alert("Оплата брони №" + booking.id + " ещё обрабатывается.");
+        }
+      } finally {
+        btnBook.disabled = selectedSeats.size === 0;
+      }
     });
 
-    loadMovies();
+    // Ссылки из писем ведут на главную с ?verify=... или ?reset=...
+    async function handleEmailLink() {
+      const params = new URLSearchParams(location.search);
+      const verify = params.get("verify");
+      const reset = params.get("reset");
+      if (!verify && !reset) return;
+      history.replaceState(null, "", location.pathname);
+      if (verify) {
+        const res = await fetch("/api/auth/verify", {
+          method: "POST",
+          headers: { "Content-Type": "application/json" },
+          body: JSON.stringify({ token: verify })
+        });
+        alert(res.ok ? "Email подтверждён." : "Ссылка недействительна или устарела.");
+        return;
+      }
+      const password = prompt("Новый пароль (не короче 8 символов):");
+      if (!password) return;
+      const res = await fetch("/api/auth/reset", {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify({ token: reset, password })
+      });
+      if (res.ok) {
+        clearTokens();
+        alert("Пароль изменён. Войдите с новым паролем.");
+      } else {
+        const err = await res.json().catch(() => ({}));
+        alert("Не удалось сменить пароль: " + (err.error || res.status));
+      }
+    }
+
+    handleEmailLink();
+    loadHalls().then(loadMovies);
   </script>
 </body>
 </html>`))
 	})
 
-	// At least one goroutine: background worker (e.g. heartbeat logger)
+	// Фоновые задачи раз в 15 секунд: перечитывает права ролей, продвигает
+	// статусы фильмов, снимает просроченные удержания мест и отменяет
+	// неоплаченные бронирования. Сбой одной задачи не мешает остальным.
 	go func() {
-		ticker := time.NewTicker(30 * time.Second)
+		ticker := time.NewTicker(15 * time.Second)
 		defer ticker.Stop()
 		for range ticker.C {
-			log.Println("[background] cinema system running")
+			// Права ролей могли изменить через другой экземпляр сервера.
+			if err := roleSvc.Reload(context.Background()); err != nil {
+				log.Printf("[background] failed to reload roles: %v", err)
+			}
+			if n, err := svc.AdvanceStatuses(context.Background()); err != nil {
+				log.Printf("[background] failed to update movie statuses: %v", err)
+			} else if n > 0 {
+				log.Printf("[background] updated the status of %d movie(s)", n)
+			}
+			if released, err := holdSvc.ReleaseExpired(context.Background()); err != nil {
+				log.Printf("[background] failed to release expired holds: %v", err)
+			} else if len(released) > 0 {
+				log.Printf("[background] released %d expired seat hold(s)", len(released))
+			}
+			if expired, err := paymentSvc.ExpirePending(context.Background()); err != nil {
+				log.Printf("[background] failed to expire unpaid bookings: %v", err)
+			} else if expired > 0 {
+				log.Printf("[background] cancelled %d unpaid booking(s)", expired)
+			}
 		}
 	}()
 
@@ -948,32 +1740,199 @@ func main() {
 		w.Header().Set("Content-Type", "application/json")
 		_, _ = w.Write([]byte(`{"status":"ok"}`))
 	})
-	// Защита методов изменения фильмов: только роль admin может создавать/обновлять/удалять.
-	protectedMovies := middleware.RequireRoleForMethods(
+	// Защита методов изменения фильмов: создавать/обновлять/удалять можно с правом movies:write.
+	protectedMovies := auth.RequirePermissionForMethods(
 		movieHandler,
-		jwtSecret,
-		map[string][]string{
-			http.MethodPost:   {"admin"},
-			http.MethodPut:    {"admin"},
-			http.MethodDelete: {"admin"},
+		map[string]string{
+			http.MethodPost:   model.PermMoviesWrite,
+			http.MethodPut:    model.PermMoviesWrite,
+			http.MethodDelete: model.PermMoviesWrite,
 		},
 	)
 
 	http.Handle("/api/movies", protectedMovies)
 	http.Handle("/api/movies/", protectedMovies)
 
+	// Жанры: справочник открыт всем, правит тот, кто ведёт афишу (movies:write).
+	protectedGenres := auth.RequirePermissionForMethods(
+		genreHandler,
+		map[string]string{
+			http.MethodPost:   model.PermMoviesWrite,
+			http.MethodPut:    model.PermMoviesWrite,
+			http.MethodDelete: model.PermMoviesWrite,
+		},
+	)
+	http.Handle("/api/genres", protectedGenres)
+	http.Handle("/api/genres/", protectedGenres)
+
+	// Люди: карточки и фильмографию видят все, правит movies:write.
+	editPeople := func(h http.HandlerFunc) http.Handler {
+		return auth.RequirePermission(h, model.PermMoviesWrite)
+	}
+	http.HandleFunc("GET /api/people", personHandler.List)
+	http.HandleFunc("GET /api/people/{id}", personHandler.Get)
+	http.HandleFunc("GET /api/people/{id}/movies", personHandler.Movies)
+	http.Handle("POST /api/people", editPeople(personHandler.Create))
+	http.Handle("PUT /api/people/{id}", editPeople(personHandler.Update))
+	http.Handle("DELETE /api/people/{id}", editPeople(personHandler.Delete))
+
+	// Залы: чтение открыто, изменение схем — с правом halls:write.
+	protectedHalls := auth.RequirePermissionForMethods(
+		hallHandler,
+		map[string]string{
+			http.MethodPost:   model.PermHallsWrite,
+			http.MethodPut:    model.PermHallsWrite,
+			http.MethodDelete: model.PermHallsWrite,
+		},
+	)
+	http.Handle("/api/halls", protectedHalls)
+	http.Handle("/api/halls/", protectedHalls)
+
+	// Сеансы: расписание видят все, планирует роль с правом sessions:schedule.
+	protectedSessions := auth.RequirePermissionForMethods(
+		sessionHandler,
+		map[string]string{
+			http.MethodPost:   model.PermSessionsSchedule,
+			http.MethodPut:    model.PermSessionsSchedule,
+			http.MethodDelete: model.PermSessionsSchedule,
+		},
+	)
+	http.Handle("/api/sessions", protectedSessions)
+	http.Handle("/api/sessions/", protectedSessions)
+
+	// Бронирования доступны только авторизованным пользователям.
+	protectedBookings := auth.RequireAuth(bookingHandler)
+	http.Handle("/api/bookings", protectedBookings)
+	http.Handle("/api/bookings/", protectedBookings)
+
+	http.Handle("POST /api/bookings/{id}/cancel", auth.RequireAuth(http.HandlerFunc(cancellationHandler.Cancel)))
+
+	// Электронные билеты, чеки (bookings:manage) и проход в зал (tickets:checkin).
+	http.Handle("GET /api/tickets/{id}/qr.png", auth.RequireAuth(http.HandlerFunc(ticketHandler.QR)))
+	http.Handle("GET /api/bookings/{id}/ticket.pdf", auth.RequireAuth(http.HandlerFunc(ticketHandler.TicketPDF)))
+	http.Handle("GET /api/bookings/{id}/receipt.pdf", auth.RequirePermission(http.HandlerFunc(ticketHandler.ReceiptPDF), model.PermBookingsManage))
+	http.Handle("POST /api/checkin", auth.RequirePermission(http.HandlerFunc(ticketHandler.CheckIn), model.PermTicketsCheckIn))
+
+	// Журнал аудита — с правом reports:view.
+	http.Handle("/api/audit", auth.RequirePermission(auditHandler, model.PermReportsView))
+
+	// Оплата. Вебхук не требует JWT: его подлинность проверяется по подписи.
+	http.Handle("POST /api/bookings/{id}/payments", auth.RequireAuth(http.HandlerFunc(paymentHandler.Pay)))
+	http.Handle("GET /api/payments/{id}", auth.RequireAuth(http.HandlerFunc(paymentHandler.Get)))
+	http.HandleFunc("POST /api/payments/webhook", paymentHandler.Webhook)
+	http.Handle("POST /api/payments/fake/script", auth.RequirePermission(http.HandlerFunc(paymentHandler.Script), model.PermPaymentsTest))
+
+	// Удержания мест.
+	http.Handle("POST /api/sessions/{id}/holds", auth.RequireAuth(http.HandlerFunc(holdHandler.CreateForSession)))
+	http.Handle("/api/holds/", auth.RequireAuth(holdHandler))
+	http.HandleFunc("GET /api/sessions/{id}/seats", seatHandler.SeatMap)
+	http.HandleFunc("GET /api/sessions/{id}/seats/stream", seatHandler.Stream)
+	http.HandleFunc("GET /api/sessions/{id}/prices", pricingHandler.SessionPrices)
+
+	// Правила цен видит и меняет роль с правом pricing:manage.
+	protectedPricing := auth.RequirePermission(pricingHandler, model.PermPricingManage)
+	http.Handle("/api/pricing-rules", protectedPricing)
+	http.Handle("/api/pricing-rules/", protectedPricing)
+
 	// Аутентификация.
 	http.HandleFunc("/api/auth/register", authHandler.Register)
 	http.HandleFunc("/api/auth/login", authHandler.Login)
+	http.HandleFunc("/api/auth/refresh", authHandler.Refresh)
+	http.HandleFunc("/api/auth/logout", authHandler.Logout)
+	http.HandleFunc("POST /api/auth/change-password", authHandler.ChangePassword)
+	http.HandleFunc("POST /api/auth/login/2fa", authHandler.LoginSecondFactor)
+	http.Handle("POST /api/auth/2fa/enroll", auth.RequireAuth(http.HandlerFunc(authHandler.EnrollTOTP)))
+	http.Handle("POST /api/auth/2fa/confirm", auth.RequireAuth(http.HandlerFunc(authHandler.ConfirmTOTP)))
+	http.Handle("POST /api/auth/2fa/disable", auth.RequireAuth(http.HandlerFunc(authHandler.DisableTOTP)))
+	http.Handle("POST /api/auth/2fa/recovery-codes", auth.RequireAuth(http.HandlerFunc(authHandler.RecoveryCodes)))
+	http.Handle("POST /api/setup", setupHandler)
+	http.HandleFunc("POST /api/auth/verify", authHandler.Verify)
+	http.Handle("POST /api/auth/verify/resend", auth.RequireAuth(http.HandlerFunc(authHandler.ResendVerification)))
+	http.HandleFunc("POST /api/auth/forgot", authHandler.Forgot)
+	http.HandleFunc("POST /api/auth/reset", authHandler.Reset)
+	http.Handle("DELETE /api/users/{id}/sessions", auth.RequirePermission(http.HandlerFunc(authHandler.RevokeSessions), model.PermUsersManage))
+
+	// Управление пользователями.
+	manageUsers := func(h http.HandlerFunc) http.Handler {
+		return auth.RequirePermission(h, model.PermUsersManage)
+	}
+	http.Handle("GET /api/users", manageUsers(userHandler.List))
+	http.Handle("GET /api/users/{id}", manageUsers(userHandler.Get))
+	http.Handle("PUT /api/users/{id}/role", manageUsers(userHandler.SetRole))
+	http.Handle("PUT /api/users/{id}/password", manageUsers(userHandler.SetPassword))
+	http.Handle("POST /api/users/{id}/activate", manageUsers(userHandler.Activate))
+	http.Handle("POST /api/users/{id}/deactivate", manageUsers(userHandler.Deactivate))
+	http.Handle("POST /api/users/{id}/unlock", manageUsers(authHandler.Unlock))
+	http.Handle("DELETE /api/users/{id}/2fa", manageUsers(userHandler.ResetTOTP))
+
+	// Личный кабинет: пользователь берётся из токена.
+	http.Handle("GET /api/me", auth.RequireAuth(http.HandlerFunc(meHandler.Get)))
+	http.Handle("PATCH /api/me", auth.RequireAuth(http.HandlerFunc(meHandler.Update)))
+	http.Handle("DELETE /api/me", auth.RequireAuth(http.HandlerFunc(meHandler.Delete)))
+	http.Handle("GET /api/me/bookings", auth.RequireAuth(http.HandlerFunc(meHandler.Bookings)))
+
+	// Роли и права.
+	manageRoles := func(h http.HandlerFunc) http.Handler {
+		return auth.RequirePermission(h, model.PermRolesManage)
+	}
+	http.Handle("GET /api/permissions", manageRoles(roleHandler.Permissions))
+	http.Handle("GET /api/roles", manageRoles(roleHandler.List))
+	http.Handle("PUT /api/roles/{name}", manageRoles(roleHandler.Save))
+	http.Handle("DELETE /api/roles/{name}", manageRoles(roleHandler.Delete))
 
 	fmt.Println("Cinema System – Assignment 4 (Milestone 2)")
 	fmt.Println("Server listening on http://localhost" + port)
 	fmt.Println("  GET  /health         – health check")
-	fmt.Println("  GET  /api/movies     – list movies")
-	fmt.Println("  GET  /api/movies/:id – get movie")
+	fmt.Println("  GET  /api/movies?status=&q=&genre=&minRating=&sort=&cursor= – search movies, paged (now showing by default)")
+	fmt.Println("  GET  /api/movies/:id[?include=credits] – get movie, optionally with cast and crew")
 	fmt.Println("  POST /api/movies     – create movie (JSON body)")
 	fmt.Println("  PUT  /api/movies/:id – update movie")
-	fmt.Println("  DELETE /api/movies/:id – delete movie")
+	fmt.Println("  DELETE /api/movies/:id – archive movie")
+	fmt.Println("  GET  /api/genres     – genre catalog with ru/kk/en names")
+	fmt.Println("  POST/PUT/DELETE /api/genres[/:id] – manage genres (admin)")
+	fmt.Println("  GET  /api/people[/:id], /api/people/:id/movies – cast and crew, filmography")
+	fmt.Println("  POST/PUT/DELETE /api/people[/:id] – manage people (admin)")
+	fmt.Println("  GET  /api/halls      – list halls with seat layouts")
+	fmt.Println("  POST/PUT/DELETE /api/halls[/:id] – manage halls (admin)")
+	fmt.Println("  GET  /api/sessions?date=&movieId=&hallId= – list sessions")
+	fmt.Println("  POST/PUT/DELETE /api/sessions[/:id] – schedule sessions (admin)")
+	fmt.Println("  POST /api/bookings   – book seats (auth)")
+	fmt.Println("  GET  /api/bookings/:id – get booking (owner or staff)")
+	fmt.Println("  POST /api/bookings/:id/cancel – cancel a booking with refund by policy (owner or staff)")
+	fmt.Println("  GET  /api/tickets/:id/qr.png – e-ticket QR code (owner or staff)")
+	fmt.Println("  GET  /api/bookings/:id/ticket.pdf – printable tickets (owner or staff)")
+	fmt.Println("  GET  /api/bookings/:id/receipt.pdf – booking receipt (cashier or admin)")
+	fmt.Println("  POST /api/checkin    – check in a ticket at the door (cashier or usher)")
+	fmt.Println("  GET  /api/audit      – audit trail (admin)")
+	fmt.Println("  POST /api/bookings/:id/payments – pay for a booking (auth)")
+	fmt.Println("  GET  /api/payments/:id – payment status (auth)")
+	fmt.Println("  POST /api/payments/webhook – payment provider callback (signed)")
+	fmt.Println("  POST /api/payments/fake/script – script test payment outcomes (admin)")
+	fmt.Println("  GET  /api/sessions/:id/seats – seat map with seat states (ETag)")
+	fmt.Println("  GET  /api/sessions/:id/seats/stream – live seat changes (SSE)")
+	fmt.Println("  GET  /api/sessions/:id/prices – ticket prices for a session")
+	fmt.Println("  POST /api/sessions/:id/holds – hold seats for a while (auth)")
+	fmt.Println("  DELETE /api/holds/:id – release held seats")
+	fmt.Println("  POST /api/holds/:id/booking – turn a hold into a booking")
+	fmt.Println("  GET/POST/PUT/DELETE /api/pricing-rules[/:id] – manage pricing rules (admin)")
+	fmt.Println("  POST /api/auth/register, /api/auth/login – get access and refresh tokens")
+	fmt.Println("  POST /api/auth/refresh – rotate refresh token, get a new access token")
+	fmt.Println("  POST /api/auth/logout – end the current session")
+	fmt.Println("  POST /api/auth/change-password – change a temporary password and log in")
+	fmt.Println("  POST /api/auth/login/2fa – second login step with an authenticator or recovery code")
+	fmt.Println("  POST /api/auth/2fa/enroll|confirm|disable|recovery-codes – manage two-factor authentication (auth)")
+	fmt.Println("  POST /api/setup – create the first administrator with the setup token from the log")
+	fmt.Println("  POST /api/auth/verify[/resend] – confirm email by the link from the letter")
+	fmt.Println("  POST /api/auth/forgot, /api/auth/reset – reset a forgotten password by email")
+	fmt.Println("  GET/PATCH/DELETE /api/me – own profile: name, language, email, password, account deletion (auth)")
+	fmt.Println("  GET  /api/me/bookings – own upcoming and past tickets (auth)")
+	fmt.Println("  GET  /api/users[/:id] – list and search users (admin)")
+	fmt.Println("  PUT  /api/users/:id/role, /api/users/:id/password – change role, set password (admin)")
+	fmt.Println("  POST /api/users/:id/activate, /api/users/:id/deactivate – enable or disable an account (admin)")
+	fmt.Println("  POST /api/users/:id/unlock – lift a login lockout after failed attempts (admin)")
+	fmt.Println("  DELETE /api/users/:id/2fa – turn off a user's two-factor authentication (admin)")
+	fmt.Println("  DELETE /api/users/:id/sessions – revoke all sessions of a user (admin)")
+	fmt.Println("  GET  /api/permissions, GET/PUT/DELETE /api/roles[/:name] – roles and their permissions (admin)")
 	if err := http.ListenAndServe(port, nil); err != nil {
 		log.Fatal(err)
 	}
@@ -1,59 +1,169 @@
 package middleware
 
 import (
+	"context"
+	"log"
 	"net/http"
 	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// RequireRoleForMethods оборачивает handler и требует наличие одной из ролей
-// для указанных HTTP-методов. Для остальных методов доступ свободный.
-func RequireRoleForMethods(next http.Handler, jwtSecret string, methods map[string][]string) http.Handler {
-	secret := []byte(jwtSecret)
+// Identity — пользователь, от имени которого выполняется запрос (из JWT).
+type Identity struct {
+	UserID      int
+	Role        string
+	SessionID   string   // сессия входа (claim "sid")
+	Permissions []string // права роли по текущей настройке ролей
+}
 
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		allowedRoles, ok := methods[r.Method]
-		if !ok {
-			next.ServeHTTP(w, r)
-			return
+// Can сообщает, есть ли у пользователя право.
+func (id Identity) Can(permission string) bool {
+	for _, p := range id.Permissions {
+		if p == permission {
+			return true
 		}
+	}
+	return false
+}
 
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-			http.Error(w, `{"error":"missing or invalid Authorization header"}`, http.StatusUnauthorized)
-			return
-		}
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+type ctxKey int
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return secret, nil
-		})
-		if err != nil || !token.Valid {
-			http.Error(w, `{"error":"invalid token"}`, http.StatusUnauthorized)
-			return
-		}
+const identityKey ctxKey = 0
 
-		claims, ok := token.Claims.(jwt.MapClaims)
+// IdentityFromContext возвращает пользователя, положенного в контекст
+// RequireAuth, RequirePermission или RequirePermissionForMethods.
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityKey).(Identity)
+	return id, ok
+}
+
+// SessionChecker сообщает, не отозвана ли сессия входа.
+type SessionChecker interface {
+	SessionActive(ctx context.Context, sessionID string) (bool, error)
+}
+
+// PermissionSource возвращает права роли. Права не зашиваются в токен, а
+// берутся на каждом запросе, поэтому изменение роли действует сразу.
+type PermissionSource interface {
+	Permissions(role string) []string
+}
+
+// Auth проверяет access-токены: подпись, срок действия и то, что сессия, по
+// которой токен выдан, не отозвана (выход, «завершить все сессии»).
+type Auth struct {
+	secret   []byte
+	sessions SessionChecker
+	perms    PermissionSource
+}
+
+func NewAuth(jwtSecret string, sessions SessionChecker, perms PermissionSource) *Auth {
+	return &Auth{secret: []byte(jwtSecret), sessions: sessions, perms: perms}
+}
+
+// RequireAuth пропускает только запросы с валидным JWT и кладёт Identity в контекст.
+func (a *Auth) RequireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := a.authenticate(w, r)
 		if !ok {
-			http.Error(w, `{"error":"invalid token claims"}`, http.StatusUnauthorized)
 			return
 		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), identityKey, id)))
+	})
+}
+
+// RequirePermission пропускает только пользователей с правом permission.
+func (a *Auth) RequirePermission(next http.Handler, permission string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.serveWithPermission(w, r, next, permission)
+	})
+}
 
-		roleVal, ok := claims["role"].(string)
+// RequirePermissionForMethods оборачивает handler и требует право для
+// указанных HTTP-методов. Для остальных методов доступ свободный.
+func (a *Auth) RequirePermissionForMethods(next http.Handler, methods map[string]string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		permission, ok := methods[r.Method]
 		if !ok {
-			http.Error(w, `{"error":"invalid token role"}`, http.StatusUnauthorized)
+			next.ServeHTTP(w, r)
 			return
 		}
+		a.serveWithPermission(w, r, next, permission)
+	})
+}
 
-		for _, rname := range allowedRoles {
-			if rname == roleVal {
-				next.ServeHTTP(w, r)
-				return
-			}
-		}
+func (a *Auth) serveWithPermission(w http.ResponseWriter, r *http.Request, next http.Handler, permission string) {
+	id, ok := a.authenticate(w, r)
+	if !ok {
+		return
+	}
+	if !id.Can(permission) {
+		http.Error(w, `{"error":"forbidden: requires permission `+permission+`"}`, http.StatusForbidden)
+		return
+	}
+	next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), identityKey, id)))
+}
 
-		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
-	})
+// authenticate проверяет Bearer-токен. При ошибке сам пишет ответ 401 и возвращает false.
+func (a *Auth) authenticate(w http.ResponseWriter, r *http.Request) (Identity, bool) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
+		http.Error(w, `{"error":"missing or invalid Authorization header"}`, http.StatusUnauthorized)
+		return Identity{}, false
+	}
+	id, err := ParseToken(strings.TrimPrefix(authHeader, "Bearer "), a.secret)
+	if err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusUnauthorized)
+		return Identity{}, false
+	}
+
+	active, err := a.sessions.SessionActive(r.Context(), id.SessionID)
+	if err != nil {
+		log.Printf("session check failed: %v", err)
+		http.Error(w, `{"error":"cannot verify session"}`, http.StatusServiceUnavailable)
+		return Identity{}, false
+	}
+	if !active {
+		http.Error(w, `{"error":"session revoked"}`, http.StatusUnauthorized)
+		return Identity{}, false
+	}
+	id.Permissions = a.perms.Permissions(id.Role)
+	return id, true
 }
 
+type tokenError string
+
+func (e tokenError) Error() string { return string(e) }
+
+// ParseToken проверяет подпись и срок действия access-токена и достаёт из него
+// пользователя. Отзыв сессии здесь не проверяется.
+func ParseToken(tokenString string, secret []byte) (Identity, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid {
+		return Identity{}, tokenError("invalid token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return Identity{}, tokenError("invalid token claims")
+	}
+
+	roleVal, ok := claims["role"].(string)
+	if !ok {
+		return Identity{}, tokenError("invalid token role")
+	}
+	// sub записывается как число (id пользователя), в JSON оно становится float64.
+	sub, ok := claims["sub"].(float64)
+	if !ok {
+		return Identity{}, tokenError("invalid token subject")
+	}
+	// Токены без сессии (выданные до появления отзыва) не принимаются.
+	sid, ok := claims["sid"].(string)
+	if !ok || sid == "" {
+		return Identity{}, tokenError("invalid token session")
+	}
+
+	return Identity{UserID: int(sub), Role: roleVal, SessionID: sid}, nil
+}
@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// Действия, которые попадают в журнал аудита.
+const (
+	AuditBookingCancelled   = "booking.cancelled"
+	AuditSessionsRevoked    = "user.sessions_revoked"
+	AuditRefreshTokenReused = "auth.refresh_token_reused"
+	AuditPasswordReset      = "user.password_reset"
+	AuditUserRoleChanged    = "user.role_changed"
+	AuditUserDeactivated    = "user.deactivated"
+	AuditUserActivated      = "user.activated"
+	AuditPasswordSetByAdmin = "user.password_set_by_admin"
+	AuditRoleSaved          = "role.saved"
+	AuditRoleDeleted        = "role.deleted"
+	AuditAdminBootstrapped  = "user.admin_bootstrapped"
+	AuditLoginAccountLocked = "auth.account_locked"
+	AuditLoginIPLocked      = "auth.ip_locked"
+	AuditLoginUnlocked      = "auth.account_unlocked"
+	Audit2FAEnabled         = "auth.2fa_enabled"
+	Audit2FADisabled        = "auth.2fa_disabled"
+	Audit2FAReset           = "auth.2fa_reset"
+	AuditRecoveryCodeUsed   = "auth.recovery_code_used"
+	AuditRecoveryCodesNew   = "auth.recovery_codes_regenerated"
+	AuditEmailChanged       = "user.email_changed"
+	AuditPasswordChanged    = "user.password_changed"
+	AuditAccountDeleted     = "user.deleted"
+)
+
+// AuditEvent — запись журнала аудита: кто, что и над чем сделал.
+type AuditEvent struct {
+	ID        int                    `json:"id" bson:"id"`
+	Action    string                 `json:"action" bson:"action"`
+	ActorID   int                    `json:"actorId" bson:"actor_id"`
+	ActorRole string                 `json:"actorRole" bson:"actor_role"`
+	Entity    string                 `json:"entity" bson:"entity"`
+	EntityID  int                    `json:"entityId" bson:"entity_id"`
+	Details   map[string]interface{} `json:"details,omitempty" bson:"details,omitempty"`
+	At        time.Time              `json:"at" bson:"at"`
+}
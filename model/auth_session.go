@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// AuthSession — вход пользователя с одного устройства. Сессия живёт, пока
+// действует её refresh-токен; access-токены несут её id в claim "sid" и
+// перестают приниматься сразу после отзыва сессии. В базе хранится только
+// хеш текущего refresh-токена: при каждом обновлении он меняется.
+type AuthSession struct {
+	ID         string     `json:"id" bson:"id"`
+	UserID     int        `json:"userId" bson:"user_id"`
+	TokenHash  string     `json:"-" bson:"token_hash"`
+	UserAgent  string     `json:"userAgent,omitempty" bson:"user_agent,omitempty"`
+	IP         string     `json:"ip,omitempty" bson:"ip,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt" bson:"created_at"`
+	LastUsedAt time.Time  `json:"lastUsedAt" bson:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expiresAt" bson:"expires_at"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty" bson:"revoked_at,omitempty"`
+}
@@ -0,0 +1,68 @@
+package model
+
+import "time"
+
+// Статусы бронирования. Новое бронирование ждёт оплаты (pending) и держит
+// места; после вебхука провайдера оно становится paid или failed (места
+// освобождаются). Отменённое покупателем или кассиром бронирование —
+// cancelled, его места тоже освобождаются.
+const (
+	BookingPending   = "pending"
+	BookingPaid      = "paid"
+	BookingFailed    = "failed"
+	BookingCancelled = "cancelled"
+)
+
+// Типы билетов.
+const (
+	TicketAdult   = "adult"
+	TicketStudent = "student"
+	TicketChild   = "child"
+)
+
+// Booking — заказ пользователя на один сеанс. Билеты хранятся в отдельной
+// коллекции, в ответах API они подгружаются в поле Tickets.
+type Booking struct {
+	ID        int        `json:"id" bson:"id"`
+	UserID    int        `json:"userId" bson:"user_id"`
+	SessionID int        `json:"sessionId" bson:"session_id"`
+	Status    string     `json:"status" bson:"status"`
+	Total     int        `json:"total" bson:"total"` // ₸
+	CreatedAt time.Time  `json:"createdAt" bson:"created_at"`
+	PaidAt    *time.Time `json:"paidAt,omitempty" bson:"paid_at,omitempty"`
+	Tickets   []*Ticket  `json:"tickets,omitempty" bson:"-"`
+
+	// AgeCheckedBy — кассир, подтвердивший возраст покупателя на сеанс 16+/18+.
+	AgeCheckedBy int `json:"ageCheckedBy,omitempty" bson:"age_checked_by,omitempty"`
+
+	// Заполняются при отмене.
+	CancelledAt  *time.Time `json:"cancelledAt,omitempty" bson:"cancelled_at,omitempty"`
+	CancelledBy  int        `json:"cancelledBy,omitempty" bson:"cancelled_by,omitempty"`
+	CancelReason string     `json:"cancelReason,omitempty" bson:"cancel_reason,omitempty"`
+	RefundAmount int        `json:"refundAmount,omitempty" bson:"refund_amount,omitempty"` // ₸
+}
+
+// Ticket — одно место в бронировании. Пара (SessionID, Row, Seat) уникальна
+// среди неотменённых билетов — это гарантирует индекс в MongoDB.
+type Ticket struct {
+	ID        int    `json:"id" bson:"id"`
+	BookingID int    `json:"bookingId" bson:"booking_id"`
+	SessionID int    `json:"sessionId" bson:"session_id"`
+	Row       string `json:"row" bson:"row"`
+	Seat      int    `json:"seat" bson:"seat"`
+	Type      string `json:"type" bson:"type"`
+	Price     int    `json:"price" bson:"price"` // ₸
+	Cancelled bool   `json:"cancelled,omitempty" bson:"cancelled"`
+
+	// Token — подписанный токен электронного билета для QR-кода; выдаётся
+	// только по оплаченным бронированиям и не хранится в базе.
+	Token       string     `json:"token,omitempty" bson:"-"`
+	CheckedInAt *time.Time `json:"checkedInAt,omitempty" bson:"checked_in_at,omitempty"`
+	CheckedInBy int        `json:"checkedInBy,omitempty" bson:"checked_in_by,omitempty"`
+}
+
+// SeatRef — ссылка на место в зале.
+type SeatRef struct {
+	Row  string `json:"row" bson:"row"`
+	Seat int    `json:"seat" bson:"seat"`
+}
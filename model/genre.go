@@ -0,0 +1,26 @@
+package model
+
+// LocalizedName — название на языках интерфейса. Русское обязательно и
+// используется, когда перевода на нужный язык нет.
+type LocalizedName struct {
+	RU string `json:"ru" bson:"ru"`
+	KK string `json:"kk,omitempty" bson:"kk,omitempty"`
+	EN string `json:"en,omitempty" bson:"en,omitempty"`
+}
+
+// In возвращает название на языке lang (ru, kk, en).
+func (n LocalizedName) In(lang string) string {
+	switch {
+	case lang == LanguageKazakh && n.KK != "":
+		return n.KK
+	case lang == LanguageEnglish && n.EN != "":
+		return n.EN
+	}
+	return n.RU
+}
+
+// Genre — жанр из справочника. Фильмы ссылаются на жанры по id.
+type Genre struct {
+	ID   int           `json:"id" bson:"id"`
+	Name LocalizedName `json:"name" bson:"name"`
+}
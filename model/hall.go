@@ -0,0 +1,72 @@
+package model
+
+// Типы ячеек в схеме зала.
+const (
+	SeatKindSeat  = "seat"  // место для зрителя
+	SeatKindAisle = "aisle" // проход
+	SeatKindGap   = "gap"   // пустое место в схеме (колонна, выступ и т.п.)
+)
+
+// Категории мест.
+const (
+	SeatCategoryStandard   = "standard"
+	SeatCategoryVIP        = "vip"
+	SeatCategoryLoveSeat   = "loveseat"
+	SeatCategoryAccessible = "accessible"
+)
+
+// HallFormatStandard — формат обычного зала; другие форматы (imax, 4dx, ...)
+// задаются админом и учитываются в правилах цен.
+const HallFormatStandard = "standard"
+
+// Hall — кинозал со схемой мест (Hall + Seat из ERD).
+type Hall struct {
+	ID     int       `json:"id" bson:"id"`
+	Name   string    `json:"name" bson:"name"`
+	Format string    `json:"format" bson:"format"`
+	Rows   []SeatRow `json:"rows" bson:"rows"`
+}
+
+// SeatRow — ряд зала. Ячейки перечислены слева направо, если смотреть на экран.
+type SeatRow struct {
+	Label string `json:"label" bson:"label"`
+	Seats []Seat `json:"seats" bson:"seats"`
+}
+
+// Seat — ячейка ряда: место, проход или промежуток.
+// Number и Category заполняются только для Kind == "seat".
+type Seat struct {
+	Kind     string `json:"kind" bson:"kind"`
+	Number   int    `json:"number,omitempty" bson:"number,omitempty"`
+	Category string `json:"category,omitempty" bson:"category,omitempty"`
+	Blocked  bool   `json:"blocked,omitempty" bson:"blocked,omitempty"` // место выведено из продажи
+}
+
+// Capacity возвращает количество мест в зале (без проходов и промежутков).
+func (h *Hall) Capacity() int {
+	n := 0
+	for _, row := range h.Rows {
+		for _, s := range row.Seats {
+			if s.Kind == SeatKindSeat {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// FindSeat ищет место по метке ряда и номеру; возвращает nil, если такого места нет.
+func (h *Hall) FindSeat(row string, number int) *Seat {
+	for i := range h.Rows {
+		if h.Rows[i].Label != row {
+			continue
+		}
+		for j := range h.Rows[i].Seats {
+			s := &h.Rows[i].Seats[j]
+			if s.Kind == SeatKindSeat && s.Number == number {
+				return s
+			}
+		}
+	}
+	return nil
+}
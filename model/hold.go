@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// Hold — временное удержание мест на сеанс, пока покупатель оформляет заказ.
+// По истечении ExpiresAt места снова становятся свободными.
+type Hold struct {
+	ID        int       `json:"id" bson:"id"`
+	SessionID int       `json:"sessionId" bson:"session_id"`
+	UserID    int       `json:"userId" bson:"user_id"`
+	Seats     []SeatRef `json:"seats" bson:"seats"`
+	CreatedAt time.Time `json:"createdAt" bson:"created_at"`
+	ExpiresAt time.Time `json:"expiresAt" bson:"expires_at"`
+}
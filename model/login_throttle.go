@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// LoginThrottle — счётчик неудачных входов по учётной записи или IP-адресу.
+// Key: "account:<email>" или "ip:<адрес>".
+type LoginThrottle struct {
+	Key           string     `json:"key" bson:"key"`
+	Failures      int        `json:"failures" bson:"failures"`
+	LastFailureAt time.Time  `json:"lastFailureAt" bson:"last_failure_at"`
+	BlockedUntil  *time.Time `json:"blockedUntil,omitempty" bson:"blocked_until,omitempty"`
+	ExpiresAt     time.Time  `json:"-" bson:"expires_at"` // TTL: счётчик забывается
+}
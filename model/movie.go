@@ -1,13 +1,69 @@
 package model
 
-// Movie matches ERD from Assignment 3: id, title, description, duration, genre, rating.
+import "time"
+
+// Movie statuses. Tickets are sold for pre-sale and now-showing movies;
+// GET /api/movies lists only now-showing ones unless asked otherwise.
+// Archived movies keep their sessions and bookings but get no new sessions.
+const (
+	MovieAnnounced  = "announced"
+	MoviePreSale    = "presale"
+	MovieNowShowing = "now_showing"
+	MovieArchived   = "archived"
+)
+
+// MovieStatuses lists movie statuses in lifecycle order.
+var MovieStatuses = []string{MovieAnnounced, MoviePreSale, MovieNowShowing, MovieArchived}
+
+// MovieOnSale reports whether tickets for a movie with this status are sold.
+func MovieOnSale(status string) bool {
+	return status == MoviePreSale || status == MovieNowShowing
+}
+
+// Age ratings of movies.
+const (
+	AgeRating0  = "0+"
+	AgeRating6  = "6+"
+	AgeRating12 = "12+"
+	AgeRating16 = "16+"
+	AgeRating18 = "18+"
+)
+
+// AgeRatings lists valid age ratings from the mildest.
+var AgeRatings = []string{AgeRating0, AgeRating6, AgeRating12, AgeRating16, AgeRating18}
+
+// AgeRestricted reports whether a rating closes the session to children:
+// child tickets are not sold and ushers check ID at the door.
+func AgeRestricted(rating string) bool {
+	return rating == AgeRating16 || rating == AgeRating18
+}
+
+// Movie matches ERD from Assignment 3: id, title, description, duration, genres, rating.
 // BSON теги нужны для сохранения в MongoDB.
 type Movie struct {
 	ID          int     `json:"id" bson:"id"`
 	Title       string  `json:"title" bson:"title"`
 	Description string  `json:"description" bson:"description"`
 	Duration    int     `json:"duration" bson:"duration"` // minutes
-	Genre       string  `json:"genre" bson:"genre"`
+	Genres      []int   `json:"genres" bson:"genres"`     // genre IDs from /api/genres
 	Rating      float64 `json:"rating" bson:"rating"`
 	PosterURL   string  `json:"posterUrl,omitempty" bson:"poster_url,omitempty"`
+	Country     string  `json:"country,omitempty" bson:"country,omitempty"`
+	Year        int     `json:"year,omitempty" bson:"year,omitempty"`            // release year
+	AgeRating   string  `json:"ageRating,omitempty" bson:"age_rating,omitempty"` // one of AgeRatings; empty if not classified
+
+	// Lifecycle. A movie moves to now showing on ReleaseDate and to archived
+	// after EndOfRun; both transitions happen automatically.
+	Status      string     `json:"status" bson:"status"`
+	ReleaseDate *time.Time `json:"releaseDate,omitempty" bson:"release_date,omitempty"`
+	EndOfRun    *time.Time `json:"endOfRun,omitempty" bson:"end_of_run,omitempty"`
+	ArchivedAt  *time.Time `json:"archivedAt,omitempty" bson:"archived_at,omitempty"`
+
+	// Credits are cast and crew; the API returns them only on request
+	// (GET /api/movies/{id}?include=credits).
+	Credits []Credit `json:"credits,omitempty" bson:"credits,omitempty"`
+
+	// LegacyGenre is the old comma-separated genre text ("Драма, Триллер").
+	// It is only read by the migration to Genres and then removed.
+	LegacyGenre string `json:"-" bson:"genre,omitempty"`
 }
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// Статусы платежа.
+const (
+	PaymentPending   = "pending"
+	PaymentSucceeded = "succeeded"
+	PaymentFailed    = "failed"
+)
+
+// PaymentIntent — попытка оплатить бронирование через платёжного провайдера.
+type PaymentIntent struct {
+	ID            int       `json:"id" bson:"id"`
+	BookingID     int       `json:"bookingId" bson:"booking_id"`
+	UserID        int       `json:"userId" bson:"user_id"`
+	Amount        int       `json:"amount" bson:"amount"` // ₸
+	Currency      string    `json:"currency" bson:"currency"`
+	Provider      string    `json:"provider" bson:"provider"`
+	ProviderRef   string    `json:"providerRef,omitempty" bson:"provider_ref,omitempty"`
+	Status        string    `json:"status" bson:"status"`
+	FailureReason string    `json:"failureReason,omitempty" bson:"failure_reason,omitempty"`
+	CreatedAt     time.Time `json:"createdAt" bson:"created_at"`
+	UpdatedAt     time.Time `json:"updatedAt" bson:"updated_at"`
+}
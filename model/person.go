@@ -0,0 +1,36 @@
+package model
+
+// Роли в титрах фильма.
+const (
+	CreditDirector        = "director"
+	CreditActor           = "actor"
+	CreditWriter          = "writer"
+	CreditProducer        = "producer"
+	CreditComposer        = "composer"
+	CreditCinematographer = "cinematographer"
+)
+
+// CreditRoles — допустимые роли в титрах.
+var CreditRoles = []string{CreditDirector, CreditActor, CreditWriter, CreditProducer, CreditComposer, CreditCinematographer}
+
+// Person — актёр или член съёмочной группы.
+type Person struct {
+	ID           int    `json:"id" bson:"id"`
+	Name         string `json:"name" bson:"name"`
+	OriginalName string `json:"originalName,omitempty" bson:"original_name,omitempty"` // имя в оригинальном написании
+	BirthYear    int    `json:"birthYear,omitempty" bson:"birth_year,omitempty"`
+	Country      string `json:"country,omitempty" bson:"country,omitempty"`
+	PhotoURL     string `json:"photoUrl,omitempty" bson:"photo_url,omitempty"`
+}
+
+// Credit — участие человека в фильме. Титры хранятся внутри фильма и
+// показываются по возрастанию Order.
+type Credit struct {
+	PersonID  int    `json:"personId" bson:"person_id"`
+	Role      string `json:"role" bson:"role"`
+	Character string `json:"character,omitempty" bson:"character,omitempty"` // имя персонажа для актёров
+	Order     int    `json:"order" bson:"order"`
+
+	// Person подставляется в ответ по запросу и не хранится в фильме.
+	Person *Person `json:"person,omitempty" bson:"-"`
+}
@@ -0,0 +1,25 @@
+package model
+
+// PricingRule — правило ценообразования. Правило применяется к билету, если
+// совпадают все заданные условия (пустое условие подходит под любое значение).
+// Цена меняется так: price = price * Percent / 100 + Amount.
+type PricingRule struct {
+	ID           int    `json:"id" bson:"id"`
+	Name         string `json:"name" bson:"name"`
+	TicketType   string `json:"ticketType,omitempty" bson:"ticket_type,omitempty"`     // adult, student, child
+	SeatCategory string `json:"seatCategory,omitempty" bson:"seat_category,omitempty"` // standard, vip, ...
+	HallFormat   string `json:"hallFormat,omitempty" bson:"hall_format,omitempty"`     // standard, imax, ...
+	Weekdays     []int  `json:"weekdays,omitempty" bson:"weekdays,omitempty"`          // 0 — воскресенье ... 6 — суббота
+	FromTime     string `json:"fromTime,omitempty" bson:"from_time,omitempty"`         // "HH:MM", начало сеанса >= FromTime
+	ToTime       string `json:"toTime,omitempty" bson:"to_time,omitempty"`             // "HH:MM", начало сеанса < ToTime; раньше FromTime — окно через полночь
+	Percent      int    `json:"percent" bson:"percent"`                                // 100 — без изменений (0 при сохранении заменяется на 100)
+	Amount       int    `json:"amount" bson:"amount"`                                  // надбавка (или скидка, если < 0), ₸
+	Priority     int    `json:"priority" bson:"priority"`                              // правила применяются по возрастанию
+	Active       *bool  `json:"active" bson:"active"`                                  // без значения: новое правило включено, при замене остаётся прежним
+}
+
+// PriceList — цены билетов на сеанс: категория места -> тип билета -> цена, ₸.
+type PriceList struct {
+	SessionID int                       `json:"sessionId"`
+	Prices    map[string]map[string]int `json:"prices"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// Статусы возврата.
+const (
+	RefundSucceeded = "succeeded"
+	RefundFailed    = "failed"
+)
+
+// Refund — возврат денег по оплаченному бронированию через провайдера.
+type Refund struct {
+	ID            int       `json:"id" bson:"id"`
+	BookingID     int       `json:"bookingId" bson:"booking_id"`
+	PaymentID     int       `json:"paymentId" bson:"payment_id"`
+	Amount        int       `json:"amount" bson:"amount"` // ₸
+	Currency      string    `json:"currency" bson:"currency"`
+	Provider      string    `json:"provider" bson:"provider"`
+	ProviderRef   string    `json:"providerRef,omitempty" bson:"provider_ref,omitempty"`
+	Status        string    `json:"status" bson:"status"`
+	Reason        string    `json:"reason,omitempty" bson:"reason,omitempty"`
+	FailureReason string    `json:"failureReason,omitempty" bson:"failure_reason,omitempty"`
+	CreatedAt     time.Time `json:"createdAt" bson:"created_at"`
+}
@@ -0,0 +1,53 @@
+package model
+
+import "time"
+
+// Права доступа. Маршруты и сервисы проверяют права, а не названия ролей,
+// поэтому новую роль (например, «менеджер») можно завести без изменения кода.
+const (
+	PermMoviesWrite      = "movies:write"      // фильмы: создание, изменение, удаление
+	PermHallsWrite       = "halls:write"       // залы и схемы мест
+	PermSessionsSchedule = "sessions:schedule" // расписание сеансов
+	PermPricingManage    = "pricing:manage"    // правила цен
+	PermBookingsManage   = "bookings:manage"   // чужие бронирования, оплаты, билеты и чеки
+	PermBookingsRefund   = "bookings:refund"   // отмена чужих броней с возвратом, в том числе после начала сеанса
+	PermTicketsCheckIn   = "tickets:checkin"   // проход в зал по билету
+	PermReportsView      = "reports:view"      // журнал аудита и отчёты
+	PermUsersManage      = "users:manage"      // учётные записи пользователей
+	PermRolesManage      = "roles:manage"      // роли и их права
+	PermPaymentsTest     = "payments:test"     // сценарии тестового платёжного провайдера
+)
+
+// Permission — право доступа с описанием для админки.
+type Permission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Permissions — все права, которые можно выдать роли.
+var Permissions = []Permission{
+	{PermMoviesWrite, "Создавать, изменять и удалять фильмы"},
+	{PermHallsWrite, "Изменять залы и схемы мест"},
+	{PermSessionsSchedule, "Планировать и переносить сеансы"},
+	{PermPricingManage, "Просматривать и изменять правила цен"},
+	{PermBookingsManage, "Работать с чужими бронированиями, оплатами, билетами и чеками"},
+	{PermBookingsRefund, "Отменять чужие бронирования с возвратом, в том числе после начала сеанса"},
+	{PermTicketsCheckIn, "Пропускать зрителей в зал по билетам"},
+	{PermReportsView, "Просматривать журнал аудита и отчёты"},
+	{PermUsersManage, "Управлять учётными записями пользователей"},
+	{PermRolesManage, "Управлять ролями и их правами"},
+	{PermPaymentsTest, "Задавать сценарии тестового платёжного провайдера"},
+}
+
+// Role — роль пользователя и выданные ей права. Хранится в MongoDB и
+// редактируется администратором.
+type Role struct {
+	Name        string   `json:"name" bson:"name"`
+	Description string   `json:"description" bson:"description"`
+	Permissions []string `json:"permissions" bson:"permissions"`
+	BuiltIn     bool     `json:"builtIn" bson:"built_in"` // встроенную роль нельзя удалить
+	// Require2FA — вход без второго фактора запрещён: пользователь с этой
+	// ролью подключает TOTP при следующем входе.
+	Require2FA bool      `json:"require2fa" bson:"require_2fa"`
+	UpdatedAt  time.Time `json:"updatedAt" bson:"updated_at"`
+}
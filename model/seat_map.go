@@ -0,0 +1,30 @@
+package model
+
+// Состояния мест на схеме сеанса.
+const (
+	SeatStateFree       = "free"
+	SeatStateHeld       = "held"
+	SeatStateSold       = "sold"
+	SeatStateBlocked    = "blocked"
+	SeatStateAccessible = "accessible" // свободное место для маломобильных зрителей
+)
+
+// SeatMap — схема зала на конкретный сеанс с состоянием каждого места.
+type SeatMap struct {
+	SessionID int          `json:"sessionId"`
+	HallID    int          `json:"hallId"`
+	HallName  string       `json:"hallName"`
+	Rows      []SeatMapRow `json:"rows"`
+}
+
+// SeatMapRow — ряд на схеме сеанса.
+type SeatMapRow struct {
+	Label string      `json:"label"`
+	Seats []SeatState `json:"seats"`
+}
+
+// SeatState — ячейка схемы зала; State заполняется только для мест.
+type SeatState struct {
+	Seat
+	State string `json:"state,omitempty"`
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// Session — сеанс: показ фильма в зале в определённое время.
+// EndTime вычисляется сервисом из длительности фильма.
+type Session struct {
+	ID        int       `json:"id" bson:"id"`
+	MovieID   int       `json:"movieId" bson:"movie_id"`
+	HallID    int       `json:"hallId" bson:"hall_id"`
+	StartTime time.Time `json:"startTime" bson:"start_time"`
+	EndTime   time.Time `json:"endTime" bson:"end_time"`
+	Language  string    `json:"language,omitempty" bson:"language,omitempty"` // язык показа: ru, kk, en
+	Format    string    `json:"format,omitempty" bson:"format,omitempty"`     // 2D, 3D, IMAX
+	BasePrice int       `json:"basePrice" bson:"base_price"`                  // базовая цена билета, ₸
+}
@@ -1,12 +1,41 @@
 package model
 
+import "time"
+
+// Языки интерфейса и писем.
+const (
+	LanguageRussian = "ru"
+	LanguageKazakh  = "kk"
+	LanguageEnglish = "en"
+)
+
 // User представляет пользователя системы кинотеатра.
-// Role: "customer", "cashier", "admin".
+// Role: "customer", "cashier", "usher", "admin".
 type User struct {
 	ID           int    `json:"id" bson:"id"`
 	Email        string `json:"email" bson:"email"`
 	PasswordHash string `json:"-" bson:"password_hash"`
 	Name         string `json:"name" bson:"name"`
 	Role         string `json:"role" bson:"role"`
+	// EmailVerified — пользователь перешёл по ссылке из письма подтверждения.
+	EmailVerified bool `json:"emailVerified" bson:"email_verified"`
+	// Disabled — учётная запись отключена администратором: вход запрещён.
+	Disabled bool `json:"disabled" bson:"disabled"`
+	// MustChangePassword — пароль выдан администратором или из конфигурации;
+	// войти можно только после его смены.
+	MustChangePassword bool `json:"mustChangePassword" bson:"must_change_password"`
+	// Language — предпочитаемый язык (ru, kk, en); пустой — язык по умолчанию.
+	Language string `json:"language,omitempty" bson:"language,omitempty"`
+	// PendingEmail — новый адрес, который ещё не подтверждён по ссылке из письма.
+	PendingEmail string `json:"pendingEmail,omitempty" bson:"pending_email,omitempty"`
+	// DeletedAt — пользователь удалил учётную запись; личные данные стёрты.
+	DeletedAt *time.Time `json:"deletedAt,omitempty" bson:"deleted_at,omitempty"`
+
+	// Двухфакторная аутентификация (TOTP). Секреты и коды наружу не отдаются.
+	TOTPEnabled       bool     `json:"totpEnabled" bson:"totp_enabled"`
+	TOTPSecret        string   `json:"-" bson:"totp_secret,omitempty"`
+	TOTPPendingSecret string   `json:"-" bson:"totp_pending_secret,omitempty"` // выдан, но ещё не подтверждён кодом
+	TOTPLastStep      int64    `json:"-" bson:"totp_last_step,omitempty"`      // последний принятый интервал
+	RecoveryCodes     []string `json:"-" bson:"recovery_codes,omitempty"`      // SHA-256 неиспользованных кодов восстановления
 }
 
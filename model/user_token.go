@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// Назначения одноразовых токенов.
+const (
+	TokenVerifyEmail   = "verify_email"
+	TokenResetPassword = "reset_password"
+	TokenLogin2FA      = "login_2fa" // пароль проверен, ждём второй фактор
+)
+
+// UserToken — одноразовый токен из письма (подтверждение email, сброс
+// пароля) или незавершённого двухшагового входа. В базе хранится только хеш; после использования токен гаснет.
+type UserToken struct {
+	Hash      string     `bson:"hash"`
+	UserID    int        `bson:"user_id"`
+	Purpose   string     `bson:"purpose"`
+	Email     string     `bson:"email,omitempty"` // какой адрес подтверждается
+	CreatedAt time.Time  `bson:"created_at"`
+	ExpiresAt time.Time  `bson:"expires_at"`
+	UsedAt    *time.Time `bson:"used_at,omitempty"`
+}
@@ -0,0 +1,147 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Outcome — чем закончится платёж у тестового провайдера.
+type Outcome string
+
+const (
+	OutcomeSucceed Outcome = "succeed"
+	OutcomeDecline Outcome = "decline"
+	OutcomeTimeout Outcome = "timeout" // провайдер так и не присылает вебхук
+)
+
+// Тестовые номера карт, как у настоящих платёжных шлюзов.
+const (
+	CardDecline = "4000000000000002"
+	CardTimeout = "4000000000000119"
+)
+
+// FakeProvider — встроенный тестовый провайдер для оффлайн-проверки оплаты.
+// Итог платежа определяется по порядку: очередь Script, тестовый номер карты,
+// исход по умолчанию. Через delay после Charge провайдер отправляет подписанный
+// вебхук на webhookURL (кроме исхода timeout).
+type FakeProvider struct {
+	webhookURL string
+	secret     []byte
+	delay      time.Duration
+	fallback   Outcome
+	client     *http.Client
+
+	mu     sync.Mutex
+	script []Outcome
+}
+
+func NewFakeProvider(webhookURL string, secret []byte, delay time.Duration, fallback Outcome) *FakeProvider {
+	if fallback == "" {
+		fallback = OutcomeSucceed
+	}
+	return &FakeProvider{
+		webhookURL: webhookURL,
+		secret:     secret,
+		delay:      delay,
+		fallback:   fallback,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *FakeProvider) Name() string { return "fake" }
+
+// ValidOutcome сообщает, известен ли тестовому провайдеру такой исход.
+func ValidOutcome(o Outcome) bool {
+	return o == OutcomeSucceed || o == OutcomeDecline || o == OutcomeTimeout
+}
+
+// Script задаёт исходы следующих платежей (по одному на платёж), заменяя
+// ранее заданный сценарий.
+func (p *FakeProvider) Script(outcomes ...Outcome) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.script = append([]Outcome(nil), outcomes...)
+}
+
+func (p *FakeProvider) Charge(_ context.Context, req ChargeRequest) (string, error) {
+	ref := "fake_" + randomHex(12)
+	outcome := p.next(req.Card)
+	log.Printf("[payment] fake charge %s: intent %d, %d %s, outcome %s", ref, req.IntentID, req.Amount, req.Currency, outcome)
+
+	if outcome == OutcomeTimeout {
+		return ref, nil
+	}
+	ev := WebhookEvent{ProviderRef: ref, Status: StatusSucceeded}
+	if outcome == OutcomeDecline {
+		ev.Status = StatusFailed
+		ev.Reason = "card declined"
+	}
+	go func() {
+		time.Sleep(p.delay)
+		if err := p.deliver(ev); err != nil {
+			log.Printf("[payment] fake webhook for %s failed: %v", ref, err)
+		}
+	}()
+	return ref, nil
+}
+
+// Refund всегда проходит успешно.
+func (p *FakeProvider) Refund(_ context.Context, req RefundRequest) (string, error) {
+	ref := "fake_refund_" + randomHex(12)
+	log.Printf("[payment] fake refund %s: payment %s, %d %s", ref, req.PaymentRef, req.Amount, req.Currency)
+	return ref, nil
+}
+
+func (p *FakeProvider) next(card string) Outcome {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.script) > 0 {
+		o := p.script[0]
+		p.script = p.script[1:]
+		return o
+	}
+	switch strings.ReplaceAll(card, " ", "") {
+	case CardDecline:
+		return OutcomeDecline
+	case CardTimeout:
+		return OutcomeTimeout
+	}
+	return p.fallback
+}
+
+func (p *FakeProvider) deliver(ev WebhookEvent) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, p.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(p.secret, body))
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
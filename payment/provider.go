@@ -0,0 +1,67 @@
+// Package payment описывает платёжных провайдеров и подпись их вебхуков.
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Статусы, которые провайдер сообщает в вебхуке.
+const (
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// SignatureHeader — заголовок с подписью тела вебхука.
+const SignatureHeader = "X-Payment-Signature"
+
+// ChargeRequest — запрос на списание денег за бронирование.
+type ChargeRequest struct {
+	IntentID int
+	Amount   int    // ₸
+	Currency string // KZT
+	Card     string // номер карты (для тестового провайдера — сценарий)
+}
+
+// RefundRequest — запрос на возврат части или всей суммы платежа.
+type RefundRequest struct {
+	PaymentRef string // идентификатор исходного платежа у провайдера
+	Amount     int    // ₸
+	Currency   string
+}
+
+// Provider — платёжный провайдер. Charge только регистрирует платёж: итог
+// приходит позже на вебхук /api/payments/webhook, подписанный общим секретом.
+// Refund выполняется синхронно: ошибка означает, что деньги не возвращены.
+type Provider interface {
+	Name() string
+	Charge(ctx context.Context, req ChargeRequest) (providerRef string, err error)
+	Refund(ctx context.Context, req RefundRequest) (refundRef string, err error)
+}
+
+// WebhookEvent — тело вебхука от провайдера.
+type WebhookEvent struct {
+	ProviderRef string `json:"providerRef"`
+	Status      string `json:"status"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// Sign возвращает hex(HMAC-SHA256(secret, body)).
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify проверяет подпись тела за постоянное время.
+func Verify(secret, body []byte, signature string) bool {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
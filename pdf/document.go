@@ -0,0 +1,257 @@
+// Package pdf — минимальный генератор PDF для билетов и чеков: текст шрифтом
+// DejaVu Sans (с русской и казахской кириллицей), линии, прямоугольники и QR-коды. Работает целиком в
+// процессе, без внешних сервисов и утилит.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// Размеры страниц в пунктах.
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+	A5Width  = 419.53
+	A5Height = 595.28
+)
+
+// Document — PDF-документ из одной или нескольких страниц.
+type Document struct {
+	font  *font
+	title string
+	pages []*Page
+}
+
+// New создаёт пустой документ.
+func New() (*Document, error) {
+	f, err := loadFont()
+	if err != nil {
+		return nil, err
+	}
+	return &Document{font: f}, nil
+}
+
+// SetTitle задаёт заголовок документа, который показывают программы просмотра.
+func (d *Document) SetTitle(title string) {
+	d.title = title
+}
+
+// AddPage добавляет страницу размером w×h пунктов.
+func (d *Document) AddPage(w, h float64) *Page {
+	p := &Page{doc: d, width: w, height: h}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// Page — страница документа. Координаты отсчитываются от левого верхнего
+// угла, y растёт вниз; для текста y — базовая линия.
+type Page struct {
+	doc           *Document
+	width, height float64
+	content       bytes.Buffer
+}
+
+// Width возвращает ширину страницы.
+func (p *Page) Width() float64 { return p.width }
+
+// Height возвращает высоту страницы.
+func (p *Page) Height() float64 { return p.height }
+
+// SetGray задаёт цвет заливки и линий: 0 — чёрный, 1 — белый.
+func (p *Page) SetGray(g float64) {
+	fmt.Fprintf(&p.content, "%s g %s G\n", num(g), num(g))
+}
+
+// Text выводит строку кеглем size с базовой линией в (x, y).
+func (p *Page) Text(x, y, size float64, s string) {
+	if s == "" {
+		return
+	}
+	fmt.Fprintf(&p.content, "BT /F1 %s Tf %s %s Td <%s> Tj ET\n",
+		num(size), num(x), num(p.height-y), p.doc.font.encode(s))
+}
+
+// TextRight выводит строку так, чтобы она заканчивалась в x.
+func (p *Page) TextRight(x, y, size float64, s string) {
+	p.Text(x-p.TextWidth(size, s), y, size, s)
+}
+
+// TextWidth возвращает ширину строки кеглем size.
+func (p *Page) TextWidth(size float64, s string) float64 {
+	return p.doc.font.width(s) * size / 1000
+}
+
+// Line рисует отрезок толщиной width.
+func (p *Page) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s m %s %s l S\n",
+		num(width), num(x1), num(p.height-y1), num(x2), num(p.height-y2))
+}
+
+// Rect заливает прямоугольник с левым верхним углом (x, y).
+func (p *Page) Rect(x, y, w, h float64) {
+	fmt.Fprintf(&p.content, "%s %s %s %s re f\n", num(x), num(p.height-y-h), num(w), num(h))
+}
+
+// Modules рисует квадратную матрицу n×n (например, QR-код) с левым верхним
+// углом в (x, y) и стороной size; black сообщает, закрашен ли модуль.
+func (p *Page) Modules(x, y, size float64, n int, black func(col, row int) bool) {
+	cell := size / float64(n)
+	for row := 0; row < n; row++ {
+		// Соседние закрашенные модули строки объединяем в один прямоугольник.
+		for col := 0; col < n; {
+			if !black(col, row) {
+				col++
+				continue
+			}
+			start := col
+			for col < n && black(col, row) {
+				col++
+			}
+			p.Rect(x+float64(start)*cell, y+float64(row)*cell, float64(col-start)*cell, cell)
+		}
+	}
+}
+
+// Bytes собирает документ.
+func (d *Document) Bytes() ([]byte, error) {
+	w := &writer{}
+	w.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Номера объектов: 1 — каталог, 2 — дерево страниц, 3–7 — шрифт,
+	// 8 — сведения о документе, дальше по два на страницу.
+	const (
+		catalogID = 1 + iota
+		pagesID
+		type0ID
+		cidFontID
+		descriptorID
+		fontFileID
+		toUnicodeID
+		infoID
+		firstPageID
+	)
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPageID+2*i)
+	}
+	w.object(catalogID, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+	w.object(pagesID, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+
+	f := d.font
+	w.object(type0ID, fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		f.name, cidFontID, toUnicodeID))
+	w.object(cidFontID, fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /CIDToGIDMap /Identity /DW 1000 /W [%s] >>",
+		f.name, descriptorID, f.widthsArray()))
+	w.object(descriptorID, fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		f.name, f.bbox[0], f.bbox[1], f.bbox[2], f.bbox[3], f.ascent, f.descent, f.capHeight, fontFileID))
+	fontFile, err := f.subset()
+	if err != nil {
+		return nil, err
+	}
+	if err := w.stream(fontFileID, fmt.Sprintf("/Length1 %d", len(fontFile)), fontFile); err != nil {
+		return nil, err
+	}
+	if err := w.stream(toUnicodeID, "", f.toUnicode()); err != nil {
+		return nil, err
+	}
+	info := "<< /Producer (cinema-system)"
+	if d.title != "" {
+		info += " /Title " + utf16Hex(d.title)
+	}
+	w.object(infoID, info+" >>")
+
+	for i, p := range d.pages {
+		pageID := firstPageID + 2*i
+		w.object(pageID, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+			pagesID, num(p.width), num(p.height), type0ID, pageID+1))
+		if err := w.stream(pageID+1, "", p.content.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+
+	size := firstPageID + 2*len(d.pages)
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", size)
+	for id := 1; id < size; id++ {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", w.offsets[id])
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", size, catalogID, infoID, xref)
+	return w.buf.Bytes(), nil
+}
+
+// writer пишет объекты и запоминает их смещения для таблицы xref.
+type writer struct {
+	buf     bytes.Buffer
+	offsets map[int]int
+}
+
+func (w *writer) object(id int, body string) {
+	w.begin(id)
+	w.buf.WriteString(body)
+	w.buf.WriteString("\nendobj\n")
+}
+
+// stream пишет поток, сжатый FlateDecode; extra — дополнительные ключи словаря.
+func (w *writer) stream(id int, extra string, data []byte) error {
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if extra != "" {
+		extra = " " + extra
+	}
+	w.begin(id)
+	fmt.Fprintf(&w.buf, "<< /Length %d /Filter /FlateDecode%s >>\nstream\n", z.Len(), extra)
+	w.buf.Write(z.Bytes())
+	w.buf.WriteString("\nendstream\nendobj\n")
+	return nil
+}
+
+func (w *writer) begin(id int) {
+	if w.offsets == nil {
+		w.offsets = make(map[int]int)
+	}
+	w.offsets[id] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n", id)
+}
+
+// num форматирует число без лишних нулей: PDF не принимает экспоненту.
+func num(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+	return s
+}
+
+// utf16Hex кодирует строку для словарей PDF (UTF-16BE с BOM).
+func utf16Hex(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// sortedGlyphs возвращает ключи карты по возрастанию.
+func sortedGlyphs(m map[uint16]rune) []uint16 {
+	out := make([]uint16, 0, len(m))
+	for g := range m {
+		out = append(out, g)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
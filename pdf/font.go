@@ -0,0 +1,161 @@
+package pdf
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+
+	xfont "golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// dejaVuSans — DejaVu Sans (лицензия в fonts/LICENSE). В отличие от Go
+// Regular в нём есть казахские буквы (Ә Ғ Қ Ң Ө Ұ Ү Һ І) и знак ₸.
+//
+//go:embed fonts/DejaVuSans.ttf
+var dejaVuSans []byte
+
+// font — шрифт DejaVu Sans, встроенный в документ как CIDFontType2 с
+// кодировкой Identity-H: в строках пишутся номера глифов, поэтому работает
+// любой алфавит, который есть в шрифте (латиница, русская и казахская
+// кириллица). В документ попадают только использованные глифы. Символы
+// вне шрифта выводятся как «?».
+type font struct {
+	name      string
+	data      []byte
+	sfnt      *sfnt.Font
+	buf       sfnt.Buffer
+	ppem      fixed.Int26_6
+	unitsEm   float64
+	bbox      [4]int
+	ascent    int
+	descent   int
+	capHeight int
+
+	used   map[uint16]rune // глиф → символ, для ToUnicode
+	widths map[uint16]int  // ширина глифа в тысячных долях кегля
+}
+
+func loadFont() (*font, error) {
+	f, err := sfnt.Parse(dejaVuSans)
+	if err != nil {
+		return nil, fmt.Errorf("parse font: %w", err)
+	}
+	ft := &font{
+		// Префикс из шести букв по ISO 32000-1 помечает подмножество шрифта.
+		name:    "CINEMA+DejaVuSans",
+		data:    dejaVuSans,
+		sfnt:    f,
+		unitsEm: float64(f.UnitsPerEm()),
+		used:    make(map[uint16]rune),
+		widths:  make(map[uint16]int),
+	}
+	// Метрики запрашиваем при ppem = unitsPerEm, чтобы получить их в единицах шрифта.
+	ft.ppem = fixed.I(int(f.UnitsPerEm()))
+	m, err := f.Metrics(&ft.buf, ft.ppem, xfont.HintingNone)
+	if err != nil {
+		return nil, err
+	}
+	ft.ascent = ft.scale(m.Ascent)
+	ft.descent = -ft.scale(m.Descent)
+	ft.capHeight = ft.scale(m.CapHeight)
+	b, err := f.Bounds(&ft.buf, ft.ppem, xfont.HintingNone)
+	if err != nil {
+		return nil, err
+	}
+	// В sfnt ось y направлена вниз, в PDF — вверх.
+	ft.bbox = [4]int{ft.scale(b.Min.X), -ft.scale(b.Max.Y), ft.scale(b.Max.X), -ft.scale(b.Min.Y)}
+	return ft, nil
+}
+
+// scale переводит длину из единиц шрифта в тысячные доли кегля.
+func (f *font) scale(v fixed.Int26_6) int {
+	return int(float64(v) / 64 * 1000 / f.unitsEm)
+}
+
+// glyph возвращает номер глифа символа и запоминает его для словарей шрифта.
+// Символы, которых нет в шрифте, заменяются на «?».
+func (f *font) glyph(r rune) uint16 {
+	g, err := f.sfnt.GlyphIndex(&f.buf, r)
+	if err != nil || g == 0 {
+		if r == '?' {
+			return 0
+		}
+		return f.glyph('?')
+	}
+	gid := uint16(g)
+	if _, ok := f.used[gid]; !ok {
+		f.used[gid] = r
+		adv, err := f.sfnt.GlyphAdvance(&f.buf, g, f.ppem, xfont.HintingNone)
+		if err == nil {
+			f.widths[gid] = f.scale(adv)
+		}
+	}
+	return gid
+}
+
+// encode переводит строку в шестнадцатеричные номера глифов для оператора Tj.
+func (f *font) encode(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		fmt.Fprintf(&b, "%04X", f.glyph(r))
+	}
+	return b.String()
+}
+
+// width возвращает ширину строки в тысячных долях кегля.
+func (f *font) width(s string) float64 {
+	total := 0
+	for _, r := range s {
+		total += f.widths[f.glyph(r)]
+	}
+	return float64(total)
+}
+
+// subset возвращает шрифт только с использованными глифами.
+func (f *font) subset() ([]byte, error) {
+	keep := make(map[uint16]bool, len(f.used))
+	for g := range f.used {
+		keep[g] = true
+	}
+	return subsetFont(f.data, keep)
+}
+
+// widthsArray формирует массив /W с ширинами использованных глифов.
+func (f *font) widthsArray() string {
+	var b strings.Builder
+	for _, g := range sortedGlyphs(f.used) {
+		fmt.Fprintf(&b, "%d [%d] ", g, f.widths[g])
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// toUnicode строит CMap, по которому программы просмотра копируют и ищут текст.
+func (f *font) toUnicode() []byte {
+	glyphs := sortedGlyphs(f.used)
+	var b strings.Builder
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	b.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	b.WriteString("/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	b.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	// В одном блоке bfchar допускается не больше 100 записей.
+	for start := 0; start < len(glyphs); start += 100 {
+		end := start + 100
+		if end > len(glyphs) {
+			end = len(glyphs)
+		}
+		fmt.Fprintf(&b, "%d beginbfchar\n", end-start)
+		for _, g := range glyphs[start:end] {
+			fmt.Fprintf(&b, "<%04X> <", g)
+			for _, u := range utf16.Encode([]rune{f.used[g]}) {
+				fmt.Fprintf(&b, "%04X", u)
+			}
+			b.WriteString(">\n")
+		}
+		b.WriteString("endbfchar\n")
+	}
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return []byte(b.String())
+}
@@ -0,0 +1,83 @@
+package pdf
+
+import (
+	"bytes"
+	"testing"
+
+	xfont "golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+func TestKazakhLettersHaveGlyphs(t *testing.T) {
+	f, err := loadFont()
+	if err != nil {
+		t.Fatal(err)
+	}
+	question := f.glyph('?')
+	for _, r := range "ӘәҒғҚқҢңӨөҰұҮүҺһІі₸" {
+		if f.glyph(r) == question {
+			t.Errorf("%c is printed as ?", r)
+		}
+	}
+}
+
+func TestSubsetKeepsUsedGlyphsOnly(t *testing.T) {
+	f, err := loadFont()
+	if err != nil {
+		t.Fatal(err)
+	}
+	used := f.glyph('Қ')
+	// «Й» — составной глиф (И с краткой): его части должны остаться.
+	composite := f.glyph('Й')
+	data, err := f.subset()
+	if err != nil {
+		t.Fatalf("subset: %v", err)
+	}
+	if len(data) >= len(dejaVuSans)/4 {
+		t.Errorf("subset is %d bytes, full font %d", len(data), len(dejaVuSans))
+	}
+
+	sub, err := sfnt.Parse(data)
+	if err != nil {
+		t.Fatalf("subset does not parse: %v", err)
+	}
+	var buf sfnt.Buffer
+	segments := func(gid uint16) int {
+		s, err := sub.LoadGlyph(&buf, sfnt.GlyphIndex(gid), fixed.I(1000), nil)
+		if err != nil {
+			t.Fatalf("glyph %d: %v", gid, err)
+		}
+		return len(s)
+	}
+	if segments(used) == 0 || segments(composite) == 0 {
+		t.Error("used glyphs lost their outlines")
+	}
+	unused, err := sub.GlyphIndex(&buf, 'Ж')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if segments(uint16(unused)) != 0 {
+		t.Error("unused glyph kept its outline")
+	}
+	if _, err := sub.GlyphAdvance(&buf, unused, fixed.I(1000), xfont.HintingNone); err != nil {
+		t.Errorf("advance of unused glyph: %v", err)
+	}
+}
+
+func TestDocumentBytes(t *testing.T) {
+	d, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.SetTitle("Билет")
+	p := d.AddPage(A5Width, A5Height)
+	p.Text(20, 40, 12, "Қылмыс · 2 500 ₸")
+	b, err := d.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	if !bytes.HasPrefix(b, []byte("%PDF-1.4")) || !bytes.Contains(b, []byte("/BaseFont /CINEMA+DejaVuSans")) {
+		t.Error("unexpected document header or font name")
+	}
+}
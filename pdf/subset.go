@@ -0,0 +1,243 @@
+package pdf
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+)
+
+// subsetTables — таблицы TrueType, которые нужны шрифту внутри PDF
+// (ISO 32000-1, 9.9), и cmap с post, без которых шрифт не открывают многие
+// библиотеки. Остальные (GSUB, kern, ...) программы просмотра не читают:
+// глифы выбираются по номерам.
+var subsetTables = map[string]bool{
+	"OS/2": true, "cmap": true, "cvt ": true, "fpgm": true, "glyf": true, "head": true,
+	"hhea": true, "hmtx": true, "loca": true, "maxp": true, "post": true, "prep": true,
+}
+
+var errBadFont = errors.New("pdf: malformed TrueType font")
+
+// subsetFont возвращает копию шрифта, в которой контуры остались только у
+// глифов keep (и у глифов, из которых они составлены); остальные глифы
+// пустые. Номера глифов не меняются, поэтому CIDToGIDMap остаётся Identity.
+func subsetFont(data []byte, keep map[uint16]bool) ([]byte, error) {
+	tables, err := readTables(data)
+	if err != nil {
+		return nil, err
+	}
+	head, maxp, glyf, loca := tables["head"], tables["maxp"], tables["glyf"], tables["loca"]
+	if len(head) < 54 || len(maxp) < 6 || glyf == nil || loca == nil {
+		return nil, errBadFont
+	}
+	numGlyphs := int(binary.BigEndian.Uint16(maxp[4:]))
+	longLoca := binary.BigEndian.Uint16(head[50:]) == 1
+	offset := func(gid int) (int, int, error) {
+		var start, end int
+		if longLoca {
+			if len(loca) < 4*(gid+2) {
+				return 0, 0, errBadFont
+			}
+			start = int(binary.BigEndian.Uint32(loca[4*gid:]))
+			end = int(binary.BigEndian.Uint32(loca[4*gid+4:]))
+		} else {
+			if len(loca) < 2*(gid+2) {
+				return 0, 0, errBadFont
+			}
+			start = 2 * int(binary.BigEndian.Uint16(loca[2*gid:]))
+			end = 2 * int(binary.BigEndian.Uint16(loca[2*gid+2:]))
+		}
+		if start > end || end > len(glyf) {
+			return 0, 0, errBadFont
+		}
+		return start, end, nil
+	}
+
+	// Составные глифы ссылаются на другие глифы — их тоже оставляем.
+	used := map[int]bool{0: true}
+	queue := []int{0}
+	for gid := range keep {
+		if int(gid) < numGlyphs && !used[int(gid)] {
+			used[int(gid)] = true
+			queue = append(queue, int(gid))
+		}
+	}
+	for len(queue) > 0 {
+		gid := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		start, end, err := offset(gid)
+		if err != nil {
+			return nil, err
+		}
+		parts, err := glyphComponents(glyf[start:end])
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range parts {
+			if c < numGlyphs && !used[c] {
+				used[c] = true
+				queue = append(queue, c)
+			}
+		}
+	}
+
+	var newGlyf []byte
+	newLoca := make([]byte, 4*(numGlyphs+1))
+	for gid := 0; gid < numGlyphs; gid++ {
+		binary.BigEndian.PutUint32(newLoca[4*gid:], uint32(len(newGlyf)))
+		if !used[gid] {
+			continue
+		}
+		start, end, err := offset(gid)
+		if err != nil {
+			return nil, err
+		}
+		newGlyf = append(newGlyf, glyf[start:end]...)
+		for len(newGlyf)%4 != 0 {
+			newGlyf = append(newGlyf, 0)
+		}
+	}
+	binary.BigEndian.PutUint32(newLoca[4*numGlyphs:], uint32(len(newGlyf)))
+
+	newHead := append([]byte(nil), head...)
+	binary.BigEndian.PutUint32(newHead[8:], 0) // checkSumAdjustment считается ниже
+	binary.BigEndian.PutUint16(newHead[50:], 1)
+
+	out := map[string][]byte{"head": newHead, "glyf": newGlyf, "loca": newLoca}
+	if post := tables["post"]; len(post) >= 32 {
+		// post версии 3 — без имён глифов, только заголовок.
+		newPost := append([]byte(nil), post[:32]...)
+		binary.BigEndian.PutUint32(newPost, 0x00030000)
+		out["post"] = newPost
+	}
+	for tag, t := range tables {
+		if subsetTables[tag] && out[tag] == nil {
+			out[tag] = t
+		}
+	}
+	font := writeTables(out)
+	sum := checksum(font)
+	binary.BigEndian.PutUint32(font[headOffset(font):][8:], 0xB1B0AFBA-sum)
+	return font, nil
+}
+
+// glyphComponents возвращает номера глифов, из которых составлен глиф.
+func glyphComponents(g []byte) ([]int, error) {
+	if len(g) < 10 || int16(binary.BigEndian.Uint16(g)) >= 0 {
+		return nil, nil
+	}
+	const (
+		argsAreWords = 0x0001
+		haveScale    = 0x0008
+		moreParts    = 0x0020
+		haveXYScale  = 0x0040
+		haveTwoByTwo = 0x0080
+	)
+	var out []int
+	for p := 10; ; {
+		if p+4 > len(g) {
+			return nil, errBadFont
+		}
+		flags := binary.BigEndian.Uint16(g[p:])
+		out = append(out, int(binary.BigEndian.Uint16(g[p+2:])))
+		p += 4 // flags и номер глифа
+		if flags&argsAreWords != 0 {
+			p += 4
+		} else {
+			p += 2
+		}
+		switch {
+		case flags&haveScale != 0:
+			p += 2
+		case flags&haveXYScale != 0:
+			p += 4
+		case flags&haveTwoByTwo != 0:
+			p += 8
+		}
+		if flags&moreParts == 0 {
+			return out, nil
+		}
+	}
+}
+
+// readTables разбирает каталог таблиц шрифта.
+func readTables(data []byte) (map[string][]byte, error) {
+	if len(data) < 12 {
+		return nil, errBadFont
+	}
+	n := int(binary.BigEndian.Uint16(data[4:]))
+	if len(data) < 12+16*n {
+		return nil, errBadFont
+	}
+	tables := make(map[string][]byte, n)
+	for i := 0; i < n; i++ {
+		rec := data[12+16*i:]
+		off := int(binary.BigEndian.Uint32(rec[8:]))
+		length := int(binary.BigEndian.Uint32(rec[12:]))
+		if off < 0 || length < 0 || off+length > len(data) {
+			return nil, errBadFont
+		}
+		tables[string(rec[:4])] = data[off : off+length]
+	}
+	return tables, nil
+}
+
+// writeTables собирает файл шрифта из таблиц, упорядоченных по тегу.
+func writeTables(tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	n := len(tags)
+	pow := 1
+	log2 := 0
+	for pow*2 <= n {
+		pow *= 2
+		log2++
+	}
+	header := make([]byte, 12+16*n)
+	binary.BigEndian.PutUint32(header, 0x00010000)
+	binary.BigEndian.PutUint16(header[4:], uint16(n))
+	binary.BigEndian.PutUint16(header[6:], uint16(16*pow))
+	binary.BigEndian.PutUint16(header[8:], uint16(log2))
+	binary.BigEndian.PutUint16(header[10:], uint16(16*n-16*pow))
+
+	var body []byte
+	for i, tag := range tags {
+		t := tables[tag]
+		rec := header[12+16*i:]
+		copy(rec, tag)
+		binary.BigEndian.PutUint32(rec[4:], checksum(t))
+		binary.BigEndian.PutUint32(rec[8:], uint32(len(header)+len(body)))
+		binary.BigEndian.PutUint32(rec[12:], uint32(len(t)))
+		body = append(body, t...)
+		for len(body)%4 != 0 {
+			body = append(body, 0)
+		}
+	}
+	return append(header, body...)
+}
+
+// headOffset возвращает смещение таблицы head в собранном шрифте.
+func headOffset(font []byte) int {
+	n := int(binary.BigEndian.Uint16(font[4:]))
+	for i := 0; i < n; i++ {
+		rec := font[12+16*i:]
+		if string(rec[:4]) == "head" {
+			return int(binary.BigEndian.Uint32(rec[8:]))
+		}
+	}
+	return 0
+}
+
+// checksum — контрольная сумма таблицы TrueType: сумма 32-битных слов.
+func checksum(b []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(b); i += 4 {
+		var w [4]byte
+		copy(w[:], b[i:])
+		sum += binary.BigEndian.Uint32(w[:])
+	}
+	return sum
+}
@@ -0,0 +1,89 @@
+// Package realtime доставляет изменения состояния мест подписчикам (SSE).
+package realtime
+
+import (
+	"cinema-system/model"
+	"sync"
+)
+
+// SeatEvent — изменение состояния мест на сеансе. State — одно из
+// model.SeatStateFree, model.SeatStateHeld, model.SeatStateSold.
+type SeatEvent struct {
+	SessionID int             `json:"sessionId"`
+	State     string          `json:"state"`
+	Seats     []model.SeatRef `json:"seats"`
+}
+
+// Publisher публикует события об изменении мест. Сервисы зависят только от
+// него, поэтому in-process Hub можно заменить брокером (Redis, NATS и т.п.).
+type Publisher interface {
+	Publish(ev SeatEvent)
+}
+
+// Subscriber выдаёт поток событий по сеансу. cancel нужно вызвать, когда
+// подписка больше не нужна; после этого канал закрывается.
+type Subscriber interface {
+	Subscribe(sessionID int) (events <-chan SeatEvent, cancel func())
+}
+
+// Broker объединяет обе стороны.
+type Broker interface {
+	Publisher
+	Subscriber
+}
+
+// subscriberBuffer — сколько событий может накопиться у медленного клиента,
+// прежде чем новые начнут отбрасываться.
+const subscriberBuffer = 32
+
+// Hub — брокер в памяти процесса. Подходит, пока сервер запущен в одном экземпляре.
+type Hub struct {
+	mu   sync.Mutex
+	subs map[int]map[chan SeatEvent]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: make(map[int]map[chan SeatEvent]struct{})}
+}
+
+// Publish рассылает событие подписчикам сеанса, не блокируясь на медленных клиентах.
+func (h *Hub) Publish(ev SeatEvent) {
+	if len(ev.Seats) == 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs[ev.SessionID] {
+		select {
+		case ch <- ev:
+		default:
+			// Клиент не успевает читать; он догонит состояние через GET /seats.
+		}
+	}
+}
+
+// Subscribe подписывается на события сеанса.
+func (h *Hub) Subscribe(sessionID int) (<-chan SeatEvent, func()) {
+	ch := make(chan SeatEvent, subscriberBuffer)
+
+	h.mu.Lock()
+	if h.subs[sessionID] == nil {
+		h.subs[sessionID] = make(map[chan SeatEvent]struct{})
+	}
+	h.subs[sessionID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs[sessionID], ch)
+			if len(h.subs[sessionID]) == 0 {
+				delete(h.subs, sessionID)
+			}
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+	return ch, cancel
+}
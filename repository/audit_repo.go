@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"cinema-system/model"
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditFilter — отбор записей журнала; нулевые поля не фильтруют.
+type AuditFilter struct {
+	Action   string
+	ActorID  int
+	Entity   string
+	EntityID int
+	Limit    int
+}
+
+// AuditRepo — журнал аудита. Записи только добавляются.
+type AuditRepo struct {
+	coll *mongo.Collection
+}
+
+func NewAuditRepo(ctx context.Context, client *mongo.Client, dbName string) (*AuditRepo, error) {
+	coll := client.Database(dbName).Collection("audit_events")
+	_, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "entity", Value: 1}, {Key: "entity_id", Value: 1}, {Key: "at", Value: -1}}},
+		{Keys: bson.D{{Key: "actor_id", Value: 1}, {Key: "at", Value: -1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &AuditRepo{coll: coll}, nil
+}
+
+// Create добавляет запись в журнал.
+func (r *AuditRepo) Create(ctx context.Context, e *model.AuditEvent) (*model.AuditEvent, error) {
+	id, err := nextSequence(ctx, r.coll)
+	if err != nil {
+		return nil, err
+	}
+	e.ID = id
+	if _, err := r.coll.InsertOne(ctx, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Find возвращает записи журнала, начиная с самых новых.
+func (r *AuditRepo) Find(ctx context.Context, f AuditFilter) ([]*model.AuditEvent, error) {
+	filter := bson.D{}
+	if f.Action != "" {
+		filter = append(filter, bson.E{Key: "action", Value: f.Action})
+	}
+	if f.ActorID != 0 {
+		filter = append(filter, bson.E{Key: "actor_id", Value: f.ActorID})
+	}
+	if f.Entity != "" {
+		filter = append(filter, bson.E{Key: "entity", Value: f.Entity})
+	}
+	if f.EntityID != 0 {
+		filter = append(filter, bson.E{Key: "entity_id", Value: f.EntityID})
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "at", Value: -1}, {Key: "id", Value: -1}})
+	if f.Limit > 0 {
+		opts.SetLimit(int64(f.Limit))
+	}
+	cur, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var out []*model.AuditEvent
+	for cur.Next(ctx) {
+		var e model.AuditEvent
+		if err := cur.Decode(&e); err != nil {
+			return nil, err
+		}
+		out = append(out, &e)
+	}
+	return out, cur.Err()
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"cinema-system/model"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuthSessionRepo — сессии входа и их refresh-токены.
+type AuthSessionRepo struct {
+	coll *mongo.Collection
+}
+
+// NewAuthSessionRepo создаёт коллекцию auth_sessions. TTL-индекс по expires_at
+// сам удаляет сессии, у которых истёк refresh-токен.
+func NewAuthSessionRepo(ctx context.Context, client *mongo.Client, dbName string) (*AuthSessionRepo, error) {
+	coll := client.Database(dbName).Collection("auth_sessions")
+	_, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &AuthSessionRepo{coll: coll}, nil
+}
+
+// Create сохраняет новую сессию.
+func (r *AuthSessionRepo) Create(ctx context.Context, s *model.AuthSession) error {
+	_, err := r.coll.InsertOne(ctx, s)
+	return err
+}
+
+// GetByID возвращает сессию по id или nil.
+func (r *AuthSessionRepo) GetByID(ctx context.Context, id string) (*model.AuthSession, error) {
+	var s model.AuthSession
+	err := r.coll.FindOne(ctx, bson.D{{Key: "id", Value: id}}).Decode(&s)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Active сообщает, что сессия существует, не отозвана и не истекла.
+func (r *AuthSessionRepo) Active(ctx context.Context, id string, now time.Time) (bool, error) {
+	n, err := r.coll.CountDocuments(ctx, bson.D{
+		{Key: "id", Value: id},
+		{Key: "revoked_at", Value: nil},
+		{Key: "expires_at", Value: bson.D{{Key: "$gt", Value: now}}},
+	}, options.Count().SetLimit(1))
+	return n > 0, err
+}
+
+// Rotate заменяет хеш refresh-токена, только если текущий хеш равен oldHash и
+// сессия не отозвана. Возвращает false, если токен уже был использован.
+func (r *AuthSessionRepo) Rotate(ctx context.Context, id, oldHash, newHash string, now time.Time) (bool, error) {
+	res, err := r.coll.UpdateOne(ctx,
+		bson.D{{Key: "id", Value: id}, {Key: "token_hash", Value: oldHash}, {Key: "revoked_at", Value: nil}},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "token_hash", Value: newHash},
+			{Key: "last_used_at", Value: now},
+		}}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// Revoke отзывает сессию.
+func (r *AuthSessionRepo) Revoke(ctx context.Context, id string, at time.Time) error {
+	_, err := r.coll.UpdateOne(ctx,
+		bson.D{{Key: "id", Value: id}, {Key: "revoked_at", Value: nil}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "revoked_at", Value: at}}}})
+	return err
+}
+
+// RevokeAllForUser отзывает все сессии пользователя и возвращает их число.
+func (r *AuthSessionRepo) RevokeAllForUser(ctx context.Context, userID int, at time.Time) (int, error) {
+	res, err := r.coll.UpdateMany(ctx,
+		bson.D{{Key: "user_id", Value: userID}, {Key: "revoked_at", Value: nil}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "revoked_at", Value: at}}}})
+	if err != nil {
+		return 0, err
+	}
+	return int(res.ModifiedCount), nil
+}
+
+// RevokeOthersForUser отзывает все сессии пользователя, кроме keepID, и
+// возвращает их число.
+func (r *AuthSessionRepo) RevokeOthersForUser(ctx context.Context, userID int, keepID string, at time.Time) (int, error) {
+	res, err := r.coll.UpdateMany(ctx,
+		bson.D{{Key: "user_id", Value: userID}, {Key: "id", Value: bson.D{{Key: "$ne", Value: keepID}}}, {Key: "revoked_at", Value: nil}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "revoked_at", Value: at}}}})
+	if err != nil {
+		return 0, err
+	}
+	return int(res.ModifiedCount), nil
+}
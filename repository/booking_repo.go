@@ -0,0 +1,387 @@
+package repository
+
+import (
+	"cinema-system/model"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SeatsTakenError возвращается, если часть запрошенных мест уже продана.
+type SeatsTakenError struct {
+	Seats []model.SeatRef
+}
+
+func (e *SeatsTakenError) Error() string {
+	return fmt.Sprintf("%d seat(s) already taken", len(e.Seats))
+}
+
+// BookingRepo — репозиторий бронирований и билетов на MongoDB.
+type BookingRepo struct {
+	bookings *mongo.Collection
+	tickets  *mongo.Collection
+	holds    *mongo.Collection
+}
+
+// NewBookingRepo создаёт коллекции и индексы. Частичный уникальный индекс по
+// (session_id, row, seat) среди неотменённых билетов — последняя линия защиты
+// от двойной продажи места, даже если прикладная проверка будет обойдена.
+func NewBookingRepo(ctx context.Context, client *mongo.Client, dbName string) (*BookingRepo, error) {
+	db := client.Database(dbName)
+	r := &BookingRepo{
+		bookings: db.Collection("bookings"),
+		tickets:  db.Collection("tickets"),
+		holds:    db.Collection("holds"),
+	}
+
+	_, err := r.bookings.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.tickets.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "booking_id", Value: 1}}},
+		{
+			Keys: bson.D{{Key: "session_id", Value: 1}, {Key: "row", Value: 1}, {Key: "seat", Value: 1}},
+			Options: options.Index().
+				SetName("uniq_session_seat").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.D{{Key: "cancelled", Value: false}}),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Create атомарно сохраняет бронирование и его билеты. Если хотя бы одно место
+// уже продано или удержано другим пользователем, ничего не сохраняется и
+// возвращается *SeatsTakenError. Купленные места в той же транзакции убираются
+// из удержаний самого покупателя; опустевшие удержания удаляются.
+func (r *BookingRepo) Create(ctx context.Context, b *model.Booking) (*model.Booking, error) {
+	id, err := nextSequence(ctx, r.bookings)
+	if err != nil {
+		return nil, err
+	}
+	b.ID = id
+	for _, t := range b.Tickets {
+		if t.ID, err = nextSequence(ctx, r.tickets); err != nil {
+			return nil, err
+		}
+		t.BookingID = b.ID
+		t.SessionID = b.SessionID
+	}
+
+	err = runLocked(ctx, r.bookings.Database(), sessionLockKey(b.SessionID), func(sc mongo.SessionContext) error {
+		seats := make([]model.SeatRef, 0, len(b.Tickets))
+		for _, t := range b.Tickets {
+			seats = append(seats, model.SeatRef{Row: t.Row, Seat: t.Seat})
+		}
+		taken, err := soldSeats(sc, r.tickets, b.SessionID, seats)
+		if err != nil {
+			return err
+		}
+		held, err := heldSeats(sc, r.holds, b.SessionID, seats, b.UserID, b.CreatedAt)
+		if err != nil {
+			return err
+		}
+		if taken = append(taken, held...); len(taken) > 0 {
+			return &SeatsTakenError{Seats: taken}
+		}
+
+		own := bson.D{
+			{Key: "session_id", Value: b.SessionID},
+			{Key: "user_id", Value: b.UserID},
+		}
+		pull := bson.D{{Key: "$pull", Value: bson.D{{Key: "seats", Value: bson.D{{Key: "$or", Value: seatsFilter(seats)}}}}}}
+		if _, err := r.holds.UpdateMany(sc, own, pull); err != nil {
+			return err
+		}
+		if _, err := r.holds.DeleteMany(sc, append(own, bson.E{Key: "seats", Value: bson.D{{Key: "$size", Value: 0}}})); err != nil {
+			return err
+		}
+
+		if _, err := r.bookings.InsertOne(sc, b); err != nil {
+			return err
+		}
+		docs := make([]interface{}, 0, len(b.Tickets))
+		for _, t := range b.Tickets {
+			docs = append(docs, t)
+		}
+		_, err = r.tickets.InsertMany(sc, docs)
+		return err
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		// Сработал уникальный индекс — место заняли в обход блокировки.
+		return nil, &SeatsTakenError{}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// GetByID возвращает бронирование вместе с билетами или nil, если не найдено.
+func (r *BookingRepo) GetByID(ctx context.Context, id int) (*model.Booking, error) {
+	var b model.Booking
+	err := r.bookings.FindOne(ctx, bson.D{{Key: "id", Value: id}}).Decode(&b)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if b.Tickets, err = r.ticketsByBooking(ctx, b.ID); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// ByUser возвращает все бронирования пользователя вместе с билетами, новые первыми.
+func (r *BookingRepo) ByUser(ctx context.Context, userID int) ([]*model.Booking, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cur, err := r.bookings.Find(ctx, bson.D{{Key: "user_id", Value: userID}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var out []*model.Booking
+	for cur.Next(ctx) {
+		var b model.Booking
+		if err := cur.Decode(&b); err != nil {
+			return nil, err
+		}
+		out = append(out, &b)
+	}
+	if err := cur.Err(); err != nil || len(out) == 0 {
+		return out, err
+	}
+
+	byID := make(map[int]*model.Booking, len(out))
+	ids := make(bson.A, 0, len(out))
+	for _, b := range out {
+		byID[b.ID] = b
+		ids = append(ids, b.ID)
+	}
+	topts := options.Find().SetSort(bson.D{{Key: "row", Value: 1}, {Key: "seat", Value: 1}})
+	tcur, err := r.tickets.Find(ctx, bson.D{{Key: "booking_id", Value: bson.D{{Key: "$in", Value: ids}}}}, topts)
+	if err != nil {
+		return nil, err
+	}
+	defer tcur.Close(ctx)
+
+	for tcur.Next(ctx) {
+		var t model.Ticket
+		if err := tcur.Decode(&t); err != nil {
+			return nil, err
+		}
+		if b := byID[t.BookingID]; b != nil {
+			b.Tickets = append(b.Tickets, &t)
+		}
+	}
+	return out, tcur.Err()
+}
+
+func (r *BookingRepo) ticketsByBooking(ctx context.Context, bookingID int) ([]*model.Ticket, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "row", Value: 1}, {Key: "seat", Value: 1}})
+	cur, err := r.tickets.Find(ctx, bson.D{{Key: "booking_id", Value: bookingID}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var out []*model.Ticket
+	for cur.Next(ctx) {
+		var t model.Ticket
+		if err := cur.Decode(&t); err != nil {
+			return nil, err
+		}
+		out = append(out, &t)
+	}
+	return out, cur.Err()
+}
+
+// GetTicket возвращает билет по id или nil.
+func (r *BookingRepo) GetTicket(ctx context.Context, id int) (*model.Ticket, error) {
+	var t model.Ticket
+	err := r.tickets.FindOne(ctx, bson.D{{Key: "id", Value: id}}).Decode(&t)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// CheckIn отмечает проход по билету. Возвращает false, если билет уже
+// использован или отменён; отметка ставится одним атомарным обновлением, так
+// что два контролёра не пропустят один билет дважды.
+func (r *BookingRepo) CheckIn(ctx context.Context, ticketID, by int, at time.Time) (bool, error) {
+	filter := bson.D{
+		{Key: "id", Value: ticketID},
+		{Key: "cancelled", Value: false},
+		{Key: "checked_in_at", Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+	res, err := r.tickets.UpdateOne(ctx, filter, bson.D{{Key: "$set", Value: bson.D{
+		{Key: "checked_in_at", Value: at},
+		{Key: "checked_in_by", Value: by},
+	}}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+func sessionLockKey(sessionID int) string {
+	return fmt.Sprintf("session:%d", sessionID)
+}
+
+// SoldSeats возвращает все проданные (неотменённые) места на сеанс.
+func (r *BookingRepo) SoldSeats(ctx context.Context, sessionID int) ([]model.SeatRef, error) {
+	filter := bson.D{{Key: "session_id", Value: sessionID}, {Key: "cancelled", Value: false}}
+	opts := options.Find().SetProjection(bson.D{{Key: "row", Value: 1}, {Key: "seat", Value: 1}})
+	cur, err := r.tickets.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var out []model.SeatRef
+	for cur.Next(ctx) {
+		var ref model.SeatRef
+		if err := cur.Decode(&ref); err != nil {
+			return nil, err
+		}
+		out = append(out, ref)
+	}
+	return out, cur.Err()
+}
+
+// CountActiveBySession считает неотменённые билеты на сеанс, включая ещё не
+// оплаченные.
+func (r *BookingRepo) CountActiveBySession(ctx context.Context, sessionID int) (int64, error) {
+	return r.tickets.CountDocuments(ctx, bson.D{{Key: "session_id", Value: sessionID}, {Key: "cancelled", Value: false}})
+}
+
+// MarkPaid переводит бронирование из pending в paid. Возвращает false, если
+// бронирование уже не ожидает оплаты.
+func (r *BookingRepo) MarkPaid(ctx context.Context, id int, at time.Time) (bool, error) {
+	res, err := r.bookings.UpdateOne(ctx,
+		bson.D{{Key: "id", Value: id}, {Key: "status", Value: model.BookingPending}},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "status", Value: model.BookingPaid},
+			{Key: "paid_at", Value: at},
+		}}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// MarkFailed переводит бронирование из pending в failed и отменяет его билеты,
+// освобождая места. Возвращает бронирование с билетами или nil, если оно уже не
+// ожидало оплаты.
+func (r *BookingRepo) MarkFailed(ctx context.Context, id int) (*model.Booking, error) {
+	var b *model.Booking
+	err := runLocked(ctx, r.bookings.Database(), bookingLockKey(id), func(sc mongo.SessionContext) error {
+		b = nil
+		res, err := r.bookings.UpdateOne(sc,
+			bson.D{{Key: "id", Value: id}, {Key: "status", Value: model.BookingPending}},
+			bson.D{{Key: "$set", Value: bson.D{{Key: "status", Value: model.BookingFailed}}}})
+		if err != nil || res.ModifiedCount == 0 {
+			return err
+		}
+		if _, err := r.tickets.UpdateMany(sc,
+			bson.D{{Key: "booking_id", Value: id}},
+			bson.D{{Key: "$set", Value: bson.D{{Key: "cancelled", Value: true}}}}); err != nil {
+			return err
+		}
+		b, err = r.GetByID(sc, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// CancelRequest — кто, когда и почему отменяет бронирование.
+type CancelRequest struct {
+	By           int
+	Reason       string
+	RefundAmount int
+	At           time.Time
+}
+
+// Cancel переводит бронирование из pending или paid в cancelled и отменяет его
+// билеты, освобождая места. Возвращает бронирование с билетами или nil, если
+// оно уже не в одном из этих статусов (например, его отменили параллельно).
+func (r *BookingRepo) Cancel(ctx context.Context, id int, req CancelRequest) (*model.Booking, error) {
+	var b *model.Booking
+	err := runLocked(ctx, r.bookings.Database(), bookingLockKey(id), func(sc mongo.SessionContext) error {
+		b = nil
+		filter := bson.D{
+			{Key: "id", Value: id},
+			{Key: "status", Value: bson.D{{Key: "$in", Value: bson.A{model.BookingPending, model.BookingPaid}}}},
+		}
+		res, err := r.bookings.UpdateOne(sc, filter, bson.D{{Key: "$set", Value: bson.D{
+			{Key: "status", Value: model.BookingCancelled},
+			{Key: "cancelled_at", Value: req.At},
+			{Key: "cancelled_by", Value: req.By},
+			{Key: "cancel_reason", Value: req.Reason},
+			{Key: "refund_amount", Value: req.RefundAmount},
+		}}})
+		if err != nil || res.ModifiedCount == 0 {
+			return err
+		}
+		if _, err := r.tickets.UpdateMany(sc,
+			bson.D{{Key: "booking_id", Value: id}},
+			bson.D{{Key: "$set", Value: bson.D{{Key: "cancelled", Value: true}}}}); err != nil {
+			return err
+		}
+		b, err = r.GetByID(sc, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// PendingCreatedBefore возвращает бронирования, ожидающие оплаты с момента до t.
+func (r *BookingRepo) PendingCreatedBefore(ctx context.Context, t time.Time) ([]*model.Booking, error) {
+	filter := bson.D{
+		{Key: "status", Value: model.BookingPending},
+		{Key: "created_at", Value: bson.D{{Key: "$lt", Value: t}}},
+	}
+	cur, err := r.bookings.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var out []*model.Booking
+	for cur.Next(ctx) {
+		var b model.Booking
+		if err := cur.Decode(&b); err != nil {
+			return nil, err
+		}
+		out = append(out, &b)
+	}
+	return out, cur.Err()
+}
+
+func bookingLockKey(bookingID int) string {
+	return fmt.Sprintf("booking:%d", bookingID)
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"cinema-system/model"
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// genreCollation сравнивает названия жанров без учёта регистра: «драма» и
+// «Драма» — один жанр.
+var genreCollation = &options.Collation{Locale: "ru", Strength: 2}
+
+// ErrGenreExists — жанр с таким русским названием уже есть.
+var ErrGenreExists = errors.New("genre already exists")
+
+// GenreRepo — справочник жанров на MongoDB.
+type GenreRepo struct {
+	coll *mongo.Collection
+}
+
+// NewGenreRepo создаёт коллекцию с уникальным индексом по русскому названию и
+// при первом запуске заполняет справочник жанрами демо-афиши.
+func NewGenreRepo(ctx context.Context, client *mongo.Client, dbName string) (*GenreRepo, error) {
+	coll := client.Database(dbName).Collection("genres")
+	r := &GenreRepo{coll: coll}
+	_, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{
+			Keys:    bson.D{{Key: "name.ru", Value: 1}},
+			Options: options.Index().SetUnique(true).SetCollation(genreCollation),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := r.seedIfEmpty(ctx); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *GenreRepo) seedIfEmpty(ctx context.Context) error {
+	count, err := r.coll.CountDocuments(ctx, bson.D{})
+	if err != nil || count > 0 {
+		return err
+	}
+	seed := []model.LocalizedName{
+		{RU: "Аниме", KK: "Аниме", EN: "Anime"},
+		{RU: "Приключения", KK: "Шытырман оқиға", EN: "Adventure"},
+		{RU: "Романтика", KK: "Романтика", EN: "Romance"},
+		{RU: "Драма", KK: "Драма", EN: "Drama"},
+		{RU: "Криминал", KK: "Қылмыс", EN: "Crime"},
+		{RU: "Триллер", KK: "Триллер", EN: "Thriller"},
+		{RU: "Комедия", KK: "Комедия", EN: "Comedy"},
+		{RU: "Семейный", KK: "Отбасылық", EN: "Family"},
+		{RU: "Экшен", KK: "Экшн", EN: "Action"},
+		{RU: "Фэнтези", KK: "Фэнтези", EN: "Fantasy"},
+		{RU: "Биография", KK: "Өмірбаян", EN: "Biography"},
+	}
+	for _, name := range seed {
+		if _, err := r.Create(ctx, &model.Genre{Name: name}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Create сохраняет жанр и возвращает его с id. Повтор русского названия даёт
+// ErrGenreExists.
+func (r *GenreRepo) Create(ctx context.Context, g *model.Genre) (*model.Genre, error) {
+	id, err := nextSequence(ctx, r.coll)
+	if err != nil {
+		return nil, err
+	}
+	g.ID = id
+	_, err = r.coll.InsertOne(ctx, g)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrGenreExists
+	}
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// GetByID возвращает жанр по id или nil, если не найден.
+func (r *GenreRepo) GetByID(ctx context.Context, id int) (*model.Genre, error) {
+	return r.findOne(ctx, bson.D{{Key: "id", Value: id}}, options.FindOne())
+}
+
+// GetByName ищет жанр по русскому названию без учёта регистра; nil, если нет.
+func (r *GenreRepo) GetByName(ctx context.Context, name string) (*model.Genre, error) {
+	return r.findOne(ctx, bson.D{{Key: "name.ru", Value: name}}, options.FindOne().SetCollation(genreCollation))
+}
+
+func (r *GenreRepo) findOne(ctx context.Context, filter bson.D, opts *options.FindOneOptions) (*model.Genre, error) {
+	var g model.Genre
+	err := r.coll.FindOne(ctx, filter, opts).Decode(&g)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// GetAll возвращает все жанры по алфавиту русских названий.
+func (r *GenreRepo) GetAll(ctx context.Context) ([]*model.Genre, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name.ru", Value: 1}}).SetCollation(genreCollation)
+	cur, err := r.coll.Find(ctx, bson.D{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var out []*model.Genre
+	for cur.Next(ctx) {
+		var g model.Genre
+		if err := cur.Decode(&g); err != nil {
+			return nil, err
+		}
+		out = append(out, &g)
+	}
+	return out, cur.Err()
+}
+
+// CountByIDs считает, сколько из перечисленных id есть в справочнике.
+func (r *GenreRepo) CountByIDs(ctx context.Context, ids []int) (int64, error) {
+	return r.coll.CountDocuments(ctx, bson.D{{Key: "id", Value: bson.D{{Key: "$in", Value: ids}}}})
+}
+
+// Update меняет названия жанра. Возвращает false, если жанра нет, и
+// ErrGenreExists, если русское название занято другим жанром.
+func (r *GenreRepo) Update(ctx context.Context, g *model.Genre) (bool, error) {
+	res, err := r.coll.UpdateOne(ctx,
+		bson.D{{Key: "id", Value: g.ID}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "name", Value: g.Name}}}})
+	if mongo.IsDuplicateKeyError(err) {
+		return false, ErrGenreExists
+	}
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// Delete удаляет жанр по id.
+func (r *GenreRepo) Delete(ctx context.Context, id int) error {
+	_, err := r.coll.DeleteOne(ctx, bson.D{{Key: "id", Value: id}})
+	return err
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"cinema-system/model"
+	"context"
+	"fmt"
+
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// HallRepo — репозиторий залов на MongoDB.
+type HallRepo struct {
+	coll *mongo.Collection
+}
+
+// NewHallRepo инициализирует коллекцию залов и при первом запуске создаёт
+// восемь стандартных залов, которые раньше были зашиты в интерфейсе.
+func NewHallRepo(ctx context.Context, client *mongo.Client, dbName string) (*HallRepo, error) {
+	coll := client.Database(dbName).Collection("halls")
+	r := &HallRepo{coll: coll}
+	if _, err := coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}); err != nil {
+		return nil, err
+	}
+	if err := r.seedIfEmpty(ctx); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *HallRepo) seedIfEmpty(ctx context.Context) error {
+	count, err := r.coll.CountDocuments(ctx, bson.D{})
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	for i := 1; i <= 8; i++ {
+		h := &model.Hall{
+			Name:   fmt.Sprintf("Зал %d", i),
+			Format: model.HallFormatStandard,
+			Rows:   defaultLayout(8, 12),
+		}
+		if _, err := r.Create(ctx, h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// defaultLayout строит типовую схему: проход посередине ряда, места для
+// маломобильных зрителей по краям первого ряда и VIP-ряд в конце зала.
+func defaultLayout(rows, cols int) []model.SeatRow {
+	out := make([]model.SeatRow, 0, rows)
+	for r := 0; r < rows; r++ {
+		row := model.SeatRow{Label: string(rune('A' + r))}
+		for c := 1; c <= cols; c++ {
+			category := model.SeatCategoryStandard
+			switch {
+			case r == rows-1:
+				category = model.SeatCategoryVIP
+			case r == 0 && (c == 1 || c == cols):
+				category = model.SeatCategoryAccessible
+			}
+			row.Seats = append(row.Seats, model.Seat{Kind: model.SeatKindSeat, Number: c, Category: category})
+			if c == cols/2 {
+				row.Seats = append(row.Seats, model.Seat{Kind: model.SeatKindAisle})
+			}
+		}
+		out = append(out, row)
+	}
+	return out
+}
+
+// Create сохраняет новый зал.
+func (r *HallRepo) Create(ctx context.Context, h *model.Hall) (*model.Hall, error) {
+	id, err := nextSequence(ctx, r.coll)
+	if err != nil {
+		return nil, err
+	}
+	h.ID = id
+	if _, err := r.coll.InsertOne(ctx, h); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// GetByID возвращает зал по id или nil, если не найден.
+func (r *HallRepo) GetByID(ctx context.Context, id int) (*model.Hall, error) {
+	var h model.Hall
+	err := r.coll.FindOne(ctx, bson.D{{Key: "id", Value: id}}).Decode(&h)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// GetAll возвращает все залы, отсортированные по id.
+func (r *HallRepo) GetAll(ctx context.Context) ([]*model.Hall, error) {
+	cur, err := r.coll.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: "id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var out []*model.Hall
+	for cur.Next(ctx) {
+		var h model.Hall
+		if err := cur.Decode(&h); err != nil {
+			return nil, err
+		}
+		out = append(out, &h)
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Update заменяет название и схему зала. Возвращает false, если зал не найден.
+func (r *HallRepo) Update(ctx context.Context, h *model.Hall) (bool, error) {
+	update := bson.D{{
+		Key: "$set",
+		Value: bson.D{
+			{Key: "name", Value: h.Name},
+			{Key: "format", Value: h.Format},
+			{Key: "rows", Value: h.Rows},
+		},
+	}}
+	res, err := r.coll.UpdateOne(ctx, bson.D{{Key: "id", Value: h.ID}}, update)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// Delete удаляет зал по id.
+func (r *HallRepo) Delete(ctx context.Context, id int) error {
+	_, err := r.coll.DeleteOne(ctx, bson.D{{Key: "id", Value: id}})
+	return err
+}
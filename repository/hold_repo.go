@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"cinema-system/model"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// HoldRepo — репозиторий временных удержаний мест.
+type HoldRepo struct {
+	holds   *mongo.Collection
+	tickets *mongo.Collection
+}
+
+func NewHoldRepo(ctx context.Context, client *mongo.Client, dbName string) (*HoldRepo, error) {
+	db := client.Database(dbName)
+	r := &HoldRepo{
+		holds:   db.Collection("holds"),
+		tickets: db.Collection("tickets"),
+	}
+	_, err := r.holds.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "session_id", Value: 1}, {Key: "expires_at", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Create сохраняет удержание, если ни одно из мест не продано и не удержано
+// другим пользователем. Прежние удержания этого же пользователя на сеанс
+// заменяются новым; места из них, не вошедшие в новое удержание, возвращаются
+// как released. Выполняется под той же блокировкой сеанса, что и оформление
+// бронирования, поэтому удержание и покупка не могут пересечься.
+func (r *HoldRepo) Create(ctx context.Context, h *model.Hold) (hold *model.Hold, released []model.SeatRef, err error) {
+	id, err := nextSequence(ctx, r.holds)
+	if err != nil {
+		return nil, nil, err
+	}
+	h.ID = id
+
+	err = runLocked(ctx, r.holds.Database(), sessionLockKey(h.SessionID), func(sc mongo.SessionContext) error {
+		own := bson.D{
+			{Key: "session_id", Value: h.SessionID},
+			{Key: "user_id", Value: h.UserID},
+		}
+		previous, err := r.find(sc, own)
+		if err != nil {
+			return err
+		}
+		if _, err := r.holds.DeleteMany(sc, own); err != nil {
+			return err
+		}
+		released = released[:0]
+		for _, p := range previous {
+			released = append(released, seatsMinus(p.Seats, h.Seats)...)
+		}
+
+		taken, err := soldSeats(sc, r.tickets, h.SessionID, h.Seats)
+		if err != nil {
+			return err
+		}
+		held, err := heldSeats(sc, r.holds, h.SessionID, h.Seats, h.UserID, h.CreatedAt)
+		if err != nil {
+			return err
+		}
+		if taken = append(taken, held...); len(taken) > 0 {
+			return &SeatsTakenError{Seats: taken}
+		}
+
+		_, err = r.holds.InsertOne(sc, h)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return h, released, nil
+}
+
+func (r *HoldRepo) find(ctx context.Context, filter bson.D) ([]*model.Hold, error) {
+	cur, err := r.holds.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var out []*model.Hold
+	for cur.Next(ctx) {
+		var h model.Hold
+		if err := cur.Decode(&h); err != nil {
+			return nil, err
+		}
+		out = append(out, &h)
+	}
+	return out, cur.Err()
+}
+
+// seatsMinus возвращает места из a, которых нет в b.
+func seatsMinus(a, b []model.SeatRef) []model.SeatRef {
+	skip := make(map[model.SeatRef]bool, len(b))
+	for _, s := range b {
+		skip[s] = true
+	}
+	var out []model.SeatRef
+	for _, s := range a {
+		if !skip[s] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// GetByID возвращает удержание по id или nil, если его нет (в том числе снятое
+// фоновой задачей после истечения срока).
+func (r *HoldRepo) GetByID(ctx context.Context, id int) (*model.Hold, error) {
+	var h model.Hold
+	err := r.holds.FindOne(ctx, bson.D{{Key: "id", Value: id}}).Decode(&h)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// Delete снимает удержание.
+func (r *HoldRepo) Delete(ctx context.Context, id int) error {
+	_, err := r.holds.DeleteOne(ctx, bson.D{{Key: "id", Value: id}})
+	return err
+}
+
+// DeleteExpired снимает все удержания, истёкшие к моменту now, и возвращает их.
+func (r *HoldRepo) DeleteExpired(ctx context.Context, now time.Time) ([]*model.Hold, error) {
+	expired, err := r.find(ctx, bson.D{{Key: "expires_at", Value: bson.D{{Key: "$lte", Value: now}}}})
+	if err != nil || len(expired) == 0 {
+		return nil, err
+	}
+	ids := bson.A{}
+	for _, h := range expired {
+		ids = append(ids, h.ID)
+	}
+
+	_, err = r.holds.DeleteMany(ctx, bson.D{{Key: "id", Value: bson.D{{Key: "$in", Value: ids}}}})
+	if err != nil {
+		return nil, err
+	}
+	return expired, nil
+}
+
+// soldSeats возвращает те из seats, на которые на сеанс уже есть неотменённые билеты.
+func soldSeats(ctx context.Context, tickets *mongo.Collection, sessionID int, seats []model.SeatRef) ([]model.SeatRef, error) {
+	filter := bson.D{
+		{Key: "session_id", Value: sessionID},
+		{Key: "cancelled", Value: false},
+		{Key: "$or", Value: seatsFilter(seats)},
+	}
+	cur, err := tickets.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var out []model.SeatRef
+	for cur.Next(ctx) {
+		var t model.Ticket
+		if err := cur.Decode(&t); err != nil {
+			return nil, err
+		}
+		out = append(out, model.SeatRef{Row: t.Row, Seat: t.Seat})
+	}
+	return out, cur.Err()
+}
+
+// heldSeats возвращает те из seats, которые на момент now удерживают другие
+// пользователи (не exceptUserID).
+func heldSeats(ctx context.Context, holds *mongo.Collection, sessionID int, seats []model.SeatRef, exceptUserID int, now time.Time) ([]model.SeatRef, error) {
+	filter := bson.D{
+		{Key: "session_id", Value: sessionID},
+		{Key: "user_id", Value: bson.D{{Key: "$ne", Value: exceptUserID}}},
+		{Key: "expires_at", Value: bson.D{{Key: "$gt", Value: now}}},
+		{Key: "seats", Value: bson.D{{Key: "$elemMatch", Value: bson.D{{Key: "$or", Value: seatsFilter(seats)}}}}},
+	}
+	cur, err := holds.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	wanted := make(map[model.SeatRef]bool, len(seats))
+	for _, s := range seats {
+		wanted[s] = true
+	}
+	var out []model.SeatRef
+	for cur.Next(ctx) {
+		var h model.Hold
+		if err := cur.Decode(&h); err != nil {
+			return nil, err
+		}
+		for _, s := range h.Seats {
+			if wanted[s] {
+				out = append(out, s)
+			}
+		}
+	}
+	return out, cur.Err()
+}
+
+func seatsFilter(seats []model.SeatRef) bson.A {
+	or := make(bson.A, 0, len(seats))
+	for _, s := range seats {
+		or = append(or, bson.D{{Key: "row", Value: s.Row}, {Key: "seat", Value: s.Seat}})
+	}
+	return or
+}
+
+// HeldSeats возвращает все места сеанса, удержанные на момент now.
+func (r *HoldRepo) HeldSeats(ctx context.Context, sessionID int, now time.Time) ([]model.SeatRef, error) {
+	filter := bson.D{
+		{Key: "session_id", Value: sessionID},
+		{Key: "expires_at", Value: bson.D{{Key: "$gt", Value: now}}},
+	}
+	holds, err := r.find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var out []model.SeatRef
+	for _, h := range holds {
+		out = append(out, h.Seats...)
+	}
+	return out, nil
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// runLocked выполняет fn в транзакции MongoDB, предварительно «захватив» ключ
+// key в коллекции locks. Параллельные транзакции с одинаковым ключом пишут в
+// один и тот же документ и получают WriteConflict, поэтому драйвер повторяет
+// проигравшую транзакцию уже после фиксации победившей — проверка и запись
+// внутри fn выполняются атомарно относительно друг друга.
+//
+// Транзакции требуют replica set (MongoDB Atlas или локальный rs).
+func runLocked(ctx context.Context, db *mongo.Database, key string, fn func(sc mongo.SessionContext) error) error {
+	locks := db.Collection("locks")
+	filter := bson.D{{Key: "_id", Value: key}}
+
+	// Документ блокировки создаём заранее и вне транзакции: конкурентная вставка
+	// одного _id внутри транзакций дала бы ошибку duplicate key, которую драйвер
+	// не повторяет.
+	if _, err := locks.UpdateOne(ctx, filter,
+		bson.D{{Key: "$setOnInsert", Value: bson.D{{Key: "v", Value: 0}}}},
+		options.Update().SetUpsert(true)); err != nil && !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+
+	sess, err := db.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer sess.EndSession(ctx)
+
+	_, err = sess.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		if _, err := locks.UpdateOne(sc, filter, bson.D{{Key: "$inc", Value: bson.D{{Key: "v", Value: 1}}}}); err != nil {
+			return nil, err
+		}
+		return nil, fn(sc)
+	})
+	return err
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"cinema-system/model"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LoginThrottleRepo — счётчики неудачных входов.
+type LoginThrottleRepo struct {
+	coll *mongo.Collection
+}
+
+// NewLoginThrottleRepo создаёт коллекцию login_throttles; забытые счётчики
+// удаляет TTL-индекс.
+func NewLoginThrottleRepo(ctx context.Context, client *mongo.Client, dbName string) (*LoginThrottleRepo, error) {
+	coll := client.Database(dbName).Collection("login_throttles")
+	_, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "key", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &LoginThrottleRepo{coll: coll}, nil
+}
+
+// Get возвращает действующий счётчик или nil.
+func (r *LoginThrottleRepo) Get(ctx context.Context, key string, now time.Time) (*model.LoginThrottle, error) {
+	var t model.LoginThrottle
+	err := r.coll.FindOne(ctx, bson.D{
+		{Key: "key", Value: key},
+		{Key: "expires_at", Value: bson.D{{Key: "$gt", Value: now}}},
+	}).Decode(&t)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// RecordFailure атомарно увеличивает счётчик и возвращает его новое
+// состояние. Счётчик, который уже истёк, но ещё не удалён TTL-индексом,
+// начинается заново.
+func (r *LoginThrottleRepo) RecordFailure(ctx context.Context, key string, now, expiresAt time.Time) (*model.LoginThrottle, error) {
+	if _, err := r.coll.DeleteOne(ctx, bson.D{
+		{Key: "key", Value: key},
+		{Key: "expires_at", Value: bson.D{{Key: "$lte", Value: now}}},
+	}); err != nil {
+		return nil, err
+	}
+	var t model.LoginThrottle
+	err := r.coll.FindOneAndUpdate(ctx,
+		bson.D{{Key: "key", Value: key}},
+		bson.D{
+			{Key: "$inc", Value: bson.D{{Key: "failures", Value: 1}}},
+			{Key: "$set", Value: bson.D{{Key: "last_failure_at", Value: now}}},
+			{Key: "$max", Value: bson.D{{Key: "expires_at", Value: expiresAt}}},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&t)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Block запрещает попытки входа до until; счётчик живёт не меньше этого срока.
+func (r *LoginThrottleRepo) Block(ctx context.Context, key string, until time.Time) error {
+	_, err := r.coll.UpdateOne(ctx, bson.D{{Key: "key", Value: key}}, bson.D{
+		{Key: "$set", Value: bson.D{{Key: "blocked_until", Value: until}}},
+		{Key: "$max", Value: bson.D{{Key: "expires_at", Value: until}}},
+	})
+	return err
+}
+
+// Reset удаляет счётчик. Возвращает false, если его не было.
+func (r *LoginThrottleRepo) Reset(ctx context.Context, key string) (bool, error) {
+	res, err := r.coll.DeleteOne(ctx, bson.D{{Key: "key", Value: key}})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
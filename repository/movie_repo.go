@@ -5,6 +5,7 @@ import (
 	"cinema-system/model"
 
 	"errors"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -21,9 +22,33 @@ type MovieRepo struct {
 func NewMovieRepo(ctx context.Context, client *mongo.Client, dbName string) (*MovieRepo, error) {
 	coll := client.Database(dbName).Collection("movies")
 	r := &MovieRepo{coll: coll}
+	// Text index for catalog search; Russian stemming suits most titles.
+	_, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{
+			Keys:    bson.D{{Key: "title", Value: "text"}, {Key: "description", Value: "text"}},
+			Options: options.Index().SetName("movie_text").SetDefaultLanguage("russian").SetWeights(bson.D{{Key: "title", Value: 5}, {Key: "description", Value: 1}}),
+		},
+		{Keys: bson.D{{Key: "title", Value: 1}, {Key: "id", Value: 1}}},
+		{Keys: bson.D{{Key: "rating", Value: 1}, {Key: "id", Value: 1}}},
+		{Keys: bson.D{{Key: "duration", Value: 1}, {Key: "id", Value: 1}}},
+		{Keys: bson.D{{Key: "genres", Value: 1}}},
+		{Keys: bson.D{{Key: "credits.person_id", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "id", Value: 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
 	if err := r.seedIfEmpty(ctx); err != nil {
 		return nil, err
 	}
+	// Movies saved before statuses existed are on screen.
+	_, err = coll.UpdateMany(ctx,
+		bson.D{{Key: "status", Value: bson.D{{Key: "$exists", Value: false}}}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "status", Value: model.MovieNowShowing}}}})
+	if err != nil {
+		return nil, err
+	}
 	return r, nil
 }
 
@@ -37,69 +62,79 @@ func (r *MovieRepo) seedIfEmpty(ctx context.Context) error {
 	}
 
 	// Seed with a few example movies so UI and API are not empty on first run.
+	// Genres are given as text; the genre migration turns them into IDs.
 	seed := []model.Movie{
 		{
 			Title:       "Ван Пис фильм: Ред",
 			Description: "Музыкальное приключение пиратов Шляпной Команды и дивы Уты.",
 			Duration:    115,
-			Genre:       "Аниме, Приключения",
+			LegacyGenre: "Аниме, Приключения",
 			Rating:      8.6,
+			AgeRating:   model.AgeRating12,
 		},
 		{
 			Title:       "50 оттенков серого",
 			Description: "Романтическая драма о необычном контракте между Анастейшей и Кристианом Греем.",
 			Duration:    125,
-			Genre:       "Романтика, Драма",
+			LegacyGenre: "Романтика, Драма",
 			Rating:      6.1,
+			AgeRating:   model.AgeRating18,
 		},
 		{
 			Title:       "Иллюзия обмана",
 			Description: "Команда иллюзионистов совершает дерзкие ограбления прямо на сцене.",
 			Duration:    115,
-			Genre:       "Криминал, Триллер",
+			LegacyGenre: "Криминал, Триллер",
 			Rating:      7.3,
+			AgeRating:   model.AgeRating12,
 		},
 		{
 			Title:       "Один дома",
 			Description: "Мальчик, которого забыли дома на Рождество, защищает дом от грабителей.",
 			Duration:    103,
-			Genre:       "Комедия, Семейный",
+			LegacyGenre: "Комедия, Семейный",
 			Rating:      8.0,
+			AgeRating:   model.AgeRating0,
 		},
 		{
 			Title:       "Demon Slayer: Mugen Train",
 			Description: "Тандзиро и отряд охотников на демонов исследуют таинственный поезд.",
 			Duration:    118,
-			Genre:       "Аниме, Экшен",
+			LegacyGenre: "Аниме, Экшен",
 			Rating:      8.7,
+			AgeRating:   model.AgeRating16,
 		},
 		{
 			Title:       "Бойцовский клуб",
 			Description: "Офисный работник создаёт подпольный бойцовский клуб и теряет контроль.",
 			Duration:    139,
-			Genre:       "Драма, Триллер",
+			LegacyGenre: "Драма, Триллер",
 			Rating:      8.8,
+			AgeRating:   model.AgeRating18,
 		},
 		{
 			Title:       "Мост в Терабитию",
 			Description: "Двое детей создают волшебный мир, чтобы уйти от реальности.",
 			Duration:    96,
-			Genre:       "Фэнтези, Семейный",
+			LegacyGenre: "Фэнтези, Семейный",
 			Rating:      7.2,
+			AgeRating:   model.AgeRating6,
 		},
 		{
 			Title:       "Зелёная книга",
 			Description: "История дружбы музыканта и его водителя в США 60-х годов.",
 			Duration:    130,
-			Genre:       "Драма, Биография",
+			LegacyGenre: "Драма, Биография",
 			Rating:      8.2,
+			AgeRating:   model.AgeRating16,
 		},
 		{
 			Title:       "Кайтадан",
 			Description: "Современная казахстанская драма о выборе и ответственности.",
 			Duration:    110,
-			Genre:       "Драма",
+			LegacyGenre: "Драма",
 			Rating:      7.5,
+			AgeRating:   model.AgeRating12,
 		},
 	}
 
@@ -177,6 +212,116 @@ func (r *MovieRepo) GetAll() ([]*model.Movie, error) {
 	return out, nil
 }
 
+// MovieSortFields are the fields the catalog can be sorted by.
+var MovieSortFields = []string{"id", "title", "rating", "duration"}
+
+// MovieCursor marks the last movie of a page: its sort value and ID.
+type MovieCursor struct {
+	Value interface{} `json:"v"`
+	ID    int         `json:"id"`
+}
+
+// MovieFilter selects movies for the catalog; zero fields do not filter.
+type MovieFilter struct {
+	Text        string // full-text search on title and description
+	GenreID     int
+	Statuses    []string // any of these statuses; all when empty
+	MinRating   *float64
+	MaxRating   *float64
+	MinDuration int
+	MaxDuration int
+	Sort        string // one of MovieSortFields; "id" when empty
+	Desc        bool
+	After       *MovieCursor
+	Limit       int
+}
+
+// Find returns a page of movies ordered by (Sort, id) starting after f.After,
+// and the number of movies that match the filter regardless of the cursor.
+func (r *MovieRepo) Find(ctx context.Context, f MovieFilter) ([]*model.Movie, int64, error) {
+	filter := bson.D{}
+	if f.Text != "" {
+		filter = append(filter, bson.E{Key: "$text", Value: bson.D{{Key: "$search", Value: f.Text}}})
+	}
+	if len(f.Statuses) > 0 {
+		filter = append(filter, bson.E{Key: "status", Value: bson.D{{Key: "$in", Value: f.Statuses}}})
+	}
+	if f.GenreID != 0 {
+		filter = append(filter, bson.E{Key: "genres", Value: f.GenreID})
+	}
+	rating := bson.D{}
+	if f.MinRating != nil {
+		rating = append(rating, bson.E{Key: "$gte", Value: *f.MinRating})
+	}
+	if f.MaxRating != nil {
+		rating = append(rating, bson.E{Key: "$lte", Value: *f.MaxRating})
+	}
+	if len(rating) > 0 {
+		filter = append(filter, bson.E{Key: "rating", Value: rating})
+	}
+	duration := bson.D{}
+	if f.MinDuration > 0 {
+		duration = append(duration, bson.E{Key: "$gte", Value: f.MinDuration})
+	}
+	if f.MaxDuration > 0 {
+		duration = append(duration, bson.E{Key: "$lte", Value: f.MaxDuration})
+	}
+	if len(duration) > 0 {
+		filter = append(filter, bson.E{Key: "duration", Value: duration})
+	}
+
+	total, err := r.coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	field := f.Sort
+	if field == "" {
+		field = "id"
+	}
+	dir, cmp := 1, "$gt"
+	if f.Desc {
+		dir, cmp = -1, "$lt"
+	}
+	if c := f.After; c != nil {
+		if field == "id" {
+			filter = append(filter, bson.E{Key: "id", Value: bson.D{{Key: cmp, Value: c.ID}}})
+		} else {
+			filter = append(filter, bson.E{Key: "$or", Value: bson.A{
+				bson.D{{Key: field, Value: bson.D{{Key: cmp, Value: c.Value}}}},
+				bson.D{{Key: field, Value: c.Value}, {Key: "id", Value: bson.D{{Key: cmp, Value: c.ID}}}},
+			}})
+		}
+	}
+	sort := bson.D{{Key: field, Value: dir}}
+	if field != "id" {
+		sort = append(sort, bson.E{Key: "id", Value: dir})
+	}
+	opts := options.Find().SetSort(sort)
+	if f.Limit > 0 {
+		opts.SetLimit(int64(f.Limit))
+	}
+
+	cur, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cur.Close(ctx)
+
+	var out []*model.Movie
+	for cur.Next(ctx) {
+		var m model.Movie
+		if err := cur.Decode(&m); err != nil {
+			return nil, 0, err
+		}
+		out = append(out, &m)
+	}
+	if err := cur.Err(); err != nil {
+		return nil, 0, err
+	}
+	return out, total, nil
+}
+
 // Update updates an existing movie by ID.
 func (r *MovieRepo) Update(m *model.Movie) error {
 	ctx := context.Background()
@@ -186,17 +331,128 @@ func (r *MovieRepo) Update(m *model.Movie) error {
 			{Key: "title", Value: m.Title},
 			{Key: "description", Value: m.Description},
 			{Key: "duration", Value: m.Duration},
-			{Key: "genre", Value: m.Genre},
+			{Key: "genres", Value: m.Genres},
 			{Key: "rating", Value: m.Rating},
+			{Key: "country", Value: m.Country},
+			{Key: "year", Value: m.Year},
+			{Key: "age_rating", Value: m.AgeRating},
+			{Key: "credits", Value: m.Credits},
+			{Key: "status", Value: m.Status},
+			{Key: "release_date", Value: m.ReleaseDate},
+			{Key: "end_of_run", Value: m.EndOfRun},
+			{Key: "archived_at", Value: m.ArchivedAt},
 		},
 	}}
 	_, err := r.coll.UpdateOne(ctx, bson.D{{Key: "id", Value: m.ID}}, update)
 	return err
 }
 
-// Delete removes a movie by ID.
-func (r *MovieRepo) Delete(id int) error {
-	ctx := context.Background()
-	_, err := r.coll.DeleteOne(ctx, bson.D{{Key: "id", Value: id}})
+// WithLegacyGenre returns movies that still keep genres as text.
+func (r *MovieRepo) WithLegacyGenre(ctx context.Context) ([]*model.Movie, error) {
+	cur, err := r.coll.Find(ctx, bson.D{{Key: "genre", Value: bson.D{{Key: "$type", Value: "string"}}}})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var out []*model.Movie
+	for cur.Next(ctx) {
+		var m model.Movie
+		if err := cur.Decode(&m); err != nil {
+			return nil, err
+		}
+		out = append(out, &m)
+	}
+	return out, cur.Err()
+}
+
+// ReplaceLegacyGenre stores genre IDs for a movie and drops its text genre.
+func (r *MovieRepo) ReplaceLegacyGenre(ctx context.Context, id int, genres []int) error {
+	_, err := r.coll.UpdateOne(ctx, bson.D{{Key: "id", Value: id}}, bson.D{
+		{Key: "$set", Value: bson.D{{Key: "genres", Value: genres}}},
+		{Key: "$unset", Value: bson.D{{Key: "genre", Value: ""}}},
+	})
 	return err
 }
+
+// CountByGenre counts movies that reference a genre.
+func (r *MovieRepo) CountByGenre(ctx context.Context, genreID int) (int64, error) {
+	return r.coll.CountDocuments(ctx, bson.D{{Key: "genres", Value: genreID}})
+}
+
+// ByPerson returns movies that credit a person, newest release first.
+func (r *MovieRepo) ByPerson(ctx context.Context, personID int) ([]*model.Movie, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "year", Value: -1}, {Key: "id", Value: -1}})
+	cur, err := r.coll.Find(ctx, bson.D{{Key: "credits.person_id", Value: personID}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var out []*model.Movie
+	for cur.Next(ctx) {
+		var m model.Movie
+		if err := cur.Decode(&m); err != nil {
+			return nil, err
+		}
+		out = append(out, &m)
+	}
+	return out, cur.Err()
+}
+
+// CountByPerson counts movies that credit a person.
+func (r *MovieRepo) CountByPerson(ctx context.Context, personID int) (int64, error) {
+	return r.coll.CountDocuments(ctx, bson.D{{Key: "credits.person_id", Value: personID}})
+}
+
+// Archive moves a movie to the archive instead of deleting it, so its
+// sessions and bookings keep pointing at an existing document. It returns
+// false if there is no such movie.
+func (r *MovieRepo) Archive(ctx context.Context, id int, at time.Time) (bool, error) {
+	res, err := r.coll.UpdateOne(ctx, bson.D{{Key: "id", Value: id}}, bson.D{{Key: "$set", Value: bson.D{
+		{Key: "status", Value: model.MovieArchived},
+		{Key: "archived_at", Value: at},
+	}}})
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// Release puts announced and pre-sale movies on screen once their release
+// date has come. It returns the number of movies changed.
+func (r *MovieRepo) Release(ctx context.Context, now time.Time) (int, error) {
+	res, err := r.coll.UpdateMany(ctx,
+		bson.D{
+			{Key: "status", Value: bson.D{{Key: "$in", Value: bson.A{model.MovieAnnounced, model.MoviePreSale}}}},
+			{Key: "release_date", Value: bson.D{{Key: "$lte", Value: now}}},
+		},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "status", Value: model.MovieNowShowing}}}})
+	if err != nil {
+		return 0, err
+	}
+	return int(res.ModifiedCount), nil
+}
+
+// RunEnded returns movies that are not archived yet although their run ended
+// by now.
+func (r *MovieRepo) RunEnded(ctx context.Context, now time.Time) ([]*model.Movie, error) {
+	cur, err := r.coll.Find(ctx, bson.D{
+		{Key: "status", Value: bson.D{{Key: "$ne", Value: model.MovieArchived}}},
+		{Key: "end_of_run", Value: bson.D{{Key: "$lte", Value: now}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var out []*model.Movie
+	for cur.Next(ctx) {
+		var m model.Movie
+		if err := cur.Decode(&m); err != nil {
+			return nil, err
+		}
+		out = append(out, &m)
+	}
+	return out, cur.Err()
+}
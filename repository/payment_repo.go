@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"cinema-system/model"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrPaymentInProgress — по бронированию уже есть незавершённое намерение.
+var ErrPaymentInProgress = errors.New("payment is already in progress")
+
+// PaymentRepo — репозиторий платёжных намерений.
+type PaymentRepo struct {
+	coll *mongo.Collection
+}
+
+func NewPaymentRepo(ctx context.Context, client *mongo.Client, dbName string) (*PaymentRepo, error) {
+	coll := client.Database(dbName).Collection("payment_intents")
+	_, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "booking_id", Value: 1}}},
+		// Не больше одного незавершённого намерения на бронирование: два
+		// одновременных Pay не спишут деньги дважды.
+		{
+			Keys: bson.D{{Key: "booking_id", Value: 1}, {Key: "status", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.D{{Key: "status", Value: model.PaymentPending}}),
+		},
+		{
+			Keys: bson.D{{Key: "provider", Value: 1}, {Key: "provider_ref", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.D{{Key: "provider_ref", Value: bson.D{{Key: "$exists", Value: true}}}}),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &PaymentRepo{coll: coll}, nil
+}
+
+// Create сохраняет новое намерение. Второе незавершённое намерение по тому же
+// бронированию даёт ErrPaymentInProgress.
+func (r *PaymentRepo) Create(ctx context.Context, p *model.PaymentIntent) (*model.PaymentIntent, error) {
+	id, err := nextSequence(ctx, r.coll)
+	if err != nil {
+		return nil, err
+	}
+	p.ID = id
+	_, err = r.coll.InsertOne(ctx, p)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrPaymentInProgress
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetByID возвращает намерение по id или nil.
+func (r *PaymentRepo) GetByID(ctx context.Context, id int) (*model.PaymentIntent, error) {
+	return r.findOne(ctx, bson.D{{Key: "id", Value: id}})
+}
+
+// GetByProviderRef ищет намерение по идентификатору платежа у провайдера.
+func (r *PaymentRepo) GetByProviderRef(ctx context.Context, provider, ref string) (*model.PaymentIntent, error) {
+	return r.findOne(ctx, bson.D{{Key: "provider", Value: provider}, {Key: "provider_ref", Value: ref}})
+}
+
+// PendingForBooking возвращает незавершённое намерение по бронированию или nil.
+func (r *PaymentRepo) PendingForBooking(ctx context.Context, bookingID int) (*model.PaymentIntent, error) {
+	return r.findOne(ctx, bson.D{{Key: "booking_id", Value: bookingID}, {Key: "status", Value: model.PaymentPending}})
+}
+
+// SucceededForBooking возвращает успешный платёж по бронированию или nil.
+func (r *PaymentRepo) SucceededForBooking(ctx context.Context, bookingID int) (*model.PaymentIntent, error) {
+	return r.findOne(ctx, bson.D{{Key: "booking_id", Value: bookingID}, {Key: "status", Value: model.PaymentSucceeded}})
+}
+
+func (r *PaymentRepo) findOne(ctx context.Context, filter bson.D) (*model.PaymentIntent, error) {
+	var p model.PaymentIntent
+	err := r.coll.FindOne(ctx, filter).Decode(&p)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// SetProviderRef запоминает идентификатор платежа у провайдера.
+func (r *PaymentRepo) SetProviderRef(ctx context.Context, id int, ref string) error {
+	_, err := r.coll.UpdateOne(ctx, bson.D{{Key: "id", Value: id}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "provider_ref", Value: ref}}}})
+	return err
+}
+
+// Finish переводит намерение из pending в status. Возвращает false, если оно
+// уже было завершено (повторный вебхук).
+func (r *PaymentRepo) Finish(ctx context.Context, id int, status, reason string) (bool, error) {
+	set := bson.D{
+		{Key: "status", Value: status},
+		{Key: "updated_at", Value: time.Now().UTC()},
+	}
+	if reason != "" {
+		set = append(set, bson.E{Key: "failure_reason", Value: reason})
+	}
+	res, err := r.coll.UpdateOne(ctx,
+		bson.D{{Key: "id", Value: id}, {Key: "status", Value: model.PaymentPending}},
+		bson.D{{Key: "$set", Value: set}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// SucceedFailed переводит намерение из failed в succeeded: провайдер списал
+// деньги уже после того, как платёж был закрыт по таймауту. Возвращает false,
+// если намерение не в статусе failed (повторный вебхук).
+func (r *PaymentRepo) SucceedFailed(ctx context.Context, id int) (bool, error) {
+	res, err := r.coll.UpdateOne(ctx,
+		bson.D{{Key: "id", Value: id}, {Key: "status", Value: model.PaymentFailed}},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "status", Value: model.PaymentSucceeded},
+			{Key: "updated_at", Value: time.Now().UTC()},
+		}}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// FailPendingForBooking завершает все незавершённые намерения по бронированию.
+func (r *PaymentRepo) FailPendingForBooking(ctx context.Context, bookingID int, reason string) error {
+	_, err := r.coll.UpdateMany(ctx,
+		bson.D{{Key: "booking_id", Value: bookingID}, {Key: "status", Value: model.PaymentPending}},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "status", Value: model.PaymentFailed},
+			{Key: "failure_reason", Value: reason},
+			{Key: "updated_at", Value: time.Now().UTC()},
+		}}})
+	return err
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"cinema-system/model"
+	"context"
+	"errors"
+	"regexp"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PersonRepo — актёры и съёмочная группа на MongoDB.
+type PersonRepo struct {
+	coll *mongo.Collection
+}
+
+func NewPersonRepo(ctx context.Context, client *mongo.Client, dbName string) (*PersonRepo, error) {
+	coll := client.Database(dbName).Collection("people")
+	_, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "name", Value: 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &PersonRepo{coll: coll}, nil
+}
+
+// Create сохраняет человека и возвращает его с id.
+func (r *PersonRepo) Create(ctx context.Context, p *model.Person) (*model.Person, error) {
+	id, err := nextSequence(ctx, r.coll)
+	if err != nil {
+		return nil, err
+	}
+	p.ID = id
+	if _, err := r.coll.InsertOne(ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetByID возвращает человека по id или nil, если не найден.
+func (r *PersonRepo) GetByID(ctx context.Context, id int) (*model.Person, error) {
+	var p model.Person
+	err := r.coll.FindOne(ctx, bson.D{{Key: "id", Value: id}}).Decode(&p)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetByIDs возвращает людей с перечисленными id; отсутствующих в ответе нет.
+func (r *PersonRepo) GetByIDs(ctx context.Context, ids []int) (map[int]*model.Person, error) {
+	out := make(map[int]*model.Person, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+	cur, err := r.coll.Find(ctx, bson.D{{Key: "id", Value: bson.D{{Key: "$in", Value: ids}}}})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var p model.Person
+		if err := cur.Decode(&p); err != nil {
+			return nil, err
+		}
+		out[p.ID] = &p
+	}
+	return out, cur.Err()
+}
+
+// PersonFilter — отбор людей для списка; нулевые поля не фильтруют.
+type PersonFilter struct {
+	Query string // подстрока имени (в том числе оригинального), без учёта регистра
+	Skip  int
+	Limit int
+}
+
+// Find возвращает страницу людей по имени и общее число подходящих.
+func (r *PersonRepo) Find(ctx context.Context, f PersonFilter) ([]*model.Person, int64, error) {
+	filter := bson.D{}
+	if f.Query != "" {
+		re := primitive.Regex{Pattern: regexp.QuoteMeta(f.Query), Options: "i"}
+		filter = append(filter, bson.E{Key: "$or", Value: bson.A{
+			bson.D{{Key: "name", Value: re}},
+			bson.D{{Key: "original_name", Value: re}},
+		}})
+	}
+	total, err := r.coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}, {Key: "id", Value: 1}}).SetSkip(int64(f.Skip))
+	if f.Limit > 0 {
+		opts.SetLimit(int64(f.Limit))
+	}
+	cur, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cur.Close(ctx)
+
+	var out []*model.Person
+	for cur.Next(ctx) {
+		var p model.Person
+		if err := cur.Decode(&p); err != nil {
+			return nil, 0, err
+		}
+		out = append(out, &p)
+	}
+	if err := cur.Err(); err != nil {
+		return nil, 0, err
+	}
+	return out, total, nil
+}
+
+// Update заменяет данные человека. Возвращает false, если его нет.
+func (r *PersonRepo) Update(ctx context.Context, p *model.Person) (bool, error) {
+	res, err := r.coll.ReplaceOne(ctx, bson.D{{Key: "id", Value: p.ID}}, p)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// Delete удаляет человека по id.
+func (r *PersonRepo) Delete(ctx context.Context, id int) error {
+	_, err := r.coll.DeleteOne(ctx, bson.D{{Key: "id", Value: id}})
+	return err
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"cinema-system/model"
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PricingRepo — репозиторий правил ценообразования.
+type PricingRepo struct {
+	coll *mongo.Collection
+}
+
+// NewPricingRepo инициализирует коллекцию и при первом запуске заводит правила,
+// которые повторяют прежние цены интерфейса (взрослый 2500 ₸, студенческий
+// 1900 ₸, детский 1600 ₸) и добавляют типовые надбавки.
+func NewPricingRepo(ctx context.Context, client *mongo.Client, dbName string) (*PricingRepo, error) {
+	coll := client.Database(dbName).Collection("pricing_rules")
+	r := &PricingRepo{coll: coll}
+	if _, err := coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}); err != nil {
+		return nil, err
+	}
+	if err := r.seedIfEmpty(ctx); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *PricingRepo) seedIfEmpty(ctx context.Context) error {
+	count, err := r.coll.CountDocuments(ctx, bson.D{})
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	seed := []model.PricingRule{
+		{Name: "Утренние сеансы в будни", Weekdays: []int{1, 2, 3, 4, 5}, ToTime: "12:00", Percent: 80, Priority: 10},
+		{Name: "Формат IMAX", HallFormat: "imax", Percent: 100, Amount: 1000, Priority: 20},
+		{Name: "VIP-места", SeatCategory: model.SeatCategoryVIP, Percent: 100, Amount: 1000, Priority: 30},
+		{Name: "Диваны", SeatCategory: model.SeatCategoryLoveSeat, Percent: 100, Amount: 1500, Priority: 30},
+		{Name: "Студенческий билет", TicketType: model.TicketStudent, Percent: 76, Priority: 100},
+		{Name: "Детский билет", TicketType: model.TicketChild, Percent: 64, Priority: 100},
+	}
+	active := true
+	for i := range seed {
+		seed[i].Active = &active
+		if _, err := r.Create(ctx, &seed[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Create сохраняет новое правило.
+func (r *PricingRepo) Create(ctx context.Context, p *model.PricingRule) (*model.PricingRule, error) {
+	id, err := nextSequence(ctx, r.coll)
+	if err != nil {
+		return nil, err
+	}
+	p.ID = id
+	if _, err := r.coll.InsertOne(ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetByID возвращает правило по id или nil, если не найдено.
+func (r *PricingRepo) GetByID(ctx context.Context, id int) (*model.PricingRule, error) {
+	var p model.PricingRule
+	err := r.coll.FindOne(ctx, bson.D{{Key: "id", Value: id}}).Decode(&p)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetAll возвращает правила в порядке применения. activeOnly — только включённые.
+func (r *PricingRepo) GetAll(ctx context.Context, activeOnly bool) ([]*model.PricingRule, error) {
+	filter := bson.D{}
+	if activeOnly {
+		filter = bson.D{{Key: "active", Value: true}}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "priority", Value: 1}, {Key: "id", Value: 1}})
+	cur, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var out []*model.PricingRule
+	for cur.Next(ctx) {
+		var p model.PricingRule
+		if err := cur.Decode(&p); err != nil {
+			return nil, err
+		}
+		out = append(out, &p)
+	}
+	return out, cur.Err()
+}
+
+// Update заменяет правило. Возвращает false, если правило не найдено.
+func (r *PricingRepo) Update(ctx context.Context, p *model.PricingRule) (bool, error) {
+	res, err := r.coll.ReplaceOne(ctx, bson.D{{Key: "id", Value: p.ID}}, p)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// Delete удаляет правило по id.
+func (r *PricingRepo) Delete(ctx context.Context, id int) error {
+	_, err := r.coll.DeleteOne(ctx, bson.D{{Key: "id", Value: id}})
+	return err
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"cinema-system/model"
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RefundRepo — репозиторий возвратов.
+type RefundRepo struct {
+	coll *mongo.Collection
+}
+
+func NewRefundRepo(ctx context.Context, client *mongo.Client, dbName string) (*RefundRepo, error) {
+	coll := client.Database(dbName).Collection("refunds")
+	_, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "booking_id", Value: 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &RefundRepo{coll: coll}, nil
+}
+
+// Create сохраняет запись о возврате.
+func (r *RefundRepo) Create(ctx context.Context, ref *model.Refund) (*model.Refund, error) {
+	id, err := nextSequence(ctx, r.coll)
+	if err != nil {
+		return nil, err
+	}
+	ref.ID = id
+	if _, err := r.coll.InsertOne(ctx, ref); err != nil {
+		return nil, err
+	}
+	return ref, nil
+}
+
+// ByBooking возвращает возвраты по бронированию в порядке создания.
+func (r *RefundRepo) ByBooking(ctx context.Context, bookingID int) ([]*model.Refund, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "id", Value: 1}})
+	cur, err := r.coll.Find(ctx, bson.D{{Key: "booking_id", Value: bookingID}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var out []*model.Refund
+	for cur.Next(ctx) {
+		var ref model.Refund
+		if err := cur.Decode(&ref); err != nil {
+			return nil, err
+		}
+		out = append(out, &ref)
+	}
+	return out, cur.Err()
+}
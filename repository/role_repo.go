@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"cinema-system/model"
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RoleRepo — роли и их права.
+type RoleRepo struct {
+	coll *mongo.Collection
+}
+
+func NewRoleRepo(ctx context.Context, client *mongo.Client, dbName string) (*RoleRepo, error) {
+	coll := client.Database(dbName).Collection("roles")
+	_, err := coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &RoleRepo{coll: coll}, nil
+}
+
+// All возвращает все роли по имени.
+func (r *RoleRepo) All(ctx context.Context) ([]*model.Role, error) {
+	cur, err := r.coll.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var out []*model.Role
+	for cur.Next(ctx) {
+		var role model.Role
+		if err := cur.Decode(&role); err != nil {
+			return nil, err
+		}
+		out = append(out, &role)
+	}
+	return out, cur.Err()
+}
+
+// GetByName возвращает роль или nil, если её нет.
+func (r *RoleRepo) GetByName(ctx context.Context, name string) (*model.Role, error) {
+	var role model.Role
+	err := r.coll.FindOne(ctx, bson.D{{Key: "name", Value: name}}).Decode(&role)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// Save создаёт роль или заменяет существующую с тем же именем.
+func (r *RoleRepo) Save(ctx context.Context, role *model.Role) error {
+	_, err := r.coll.ReplaceOne(ctx, bson.D{{Key: "name", Value: role.Name}}, role, options.Replace().SetUpsert(true))
+	return err
+}
+
+// CreateIfMissing добавляет роль, только если роли с таким именем ещё нет:
+// права, изменённые администратором, при старте не перезаписываются.
+func (r *RoleRepo) CreateIfMissing(ctx context.Context, role *model.Role) error {
+	_, err := r.coll.UpdateOne(ctx, bson.D{{Key: "name", Value: role.Name}},
+		bson.D{{Key: "$setOnInsert", Value: role}}, options.Update().SetUpsert(true))
+	return err
+}
+
+// Delete удаляет роль. Возвращает false, если её не было.
+func (r *RoleRepo) Delete(ctx context.Context, name string) (bool, error) {
+	res, err := r.coll.DeleteOne(ctx, bson.D{{Key: "name", Value: name}})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
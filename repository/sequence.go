@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// nextSequence атомарно выдаёт следующий id для коллекции через счётчик в
+// коллекции counters. В отличие от поиска максимального id, два параллельных
+// запроса никогда не получат одинаковый номер. При первом обращении счётчик
+// инициализируется максимальным id, уже лежащим в коллекции.
+func nextSequence(ctx context.Context, coll *mongo.Collection) (int, error) {
+	counters := coll.Database().Collection("counters")
+	filter := bson.D{{Key: "_id", Value: coll.Name()}}
+	update := bson.D{{Key: "$inc", Value: bson.D{{Key: "seq", Value: 1}}}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	for {
+		var doc struct {
+			Seq int `bson:"seq"`
+		}
+		err := counters.FindOneAndUpdate(ctx, filter, update, opts).Decode(&doc)
+		if err == nil {
+			return doc.Seq, nil
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return 0, err
+		}
+
+		maxID, err := maxIntID(ctx, coll)
+		if err != nil {
+			return 0, err
+		}
+		_, err = counters.InsertOne(ctx, bson.D{{Key: "_id", Value: coll.Name()}, {Key: "seq", Value: maxID}})
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return 0, err
+		}
+		// Счётчик создан (нами или параллельным запросом) — повторяем инкремент.
+	}
+}
+
+// maxIntID возвращает максимальное значение поля id в коллекции или 0.
+func maxIntID(ctx context.Context, coll *mongo.Collection) (int, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "id", Value: -1}}).SetProjection(bson.D{{Key: "id", Value: 1}})
+	var last struct {
+		ID int `bson:"id"`
+	}
+	err := coll.FindOne(ctx, bson.D{}, opts).Decode(&last)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return last.ID, nil
+}
@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"cinema-system/model"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SessionFilter — условия выборки сеансов. Нулевые поля не участвуют в фильтре.
+type SessionFilter struct {
+	MovieID int
+	HallID  int
+	From    time.Time // начало сеанса >= From
+	To      time.Time // начало сеанса < To
+}
+
+// SessionRepo — репозиторий сеансов на MongoDB.
+type SessionRepo struct {
+	coll *mongo.Collection
+}
+
+func NewSessionRepo(ctx context.Context, client *mongo.Client, dbName string) (*SessionRepo, error) {
+	coll := client.Database(dbName).Collection("sessions")
+	_, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "hall_id", Value: 1}, {Key: "start_time", Value: 1}}},
+		{Keys: bson.D{{Key: "movie_id", Value: 1}, {Key: "start_time", Value: 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &SessionRepo{coll: coll}, nil
+}
+
+// errHallBusy прерывает транзакцию, если найден пересекающийся сеанс.
+var errHallBusy = errors.New("hall is busy")
+
+// CreateIfFree сохраняет сеанс, только если зал свободен: ни один другой сеанс
+// в этом зале не пересекается с [StartTime-buffer, EndTime+buffer). Проверка и
+// вставка выполняются в одной транзакции под блокировкой зала, поэтому два
+// параллельных запроса не смогут занять одно и то же время.
+// Если зал занят, возвращается конфликтующий сеанс и s не сохраняется.
+func (r *SessionRepo) CreateIfFree(ctx context.Context, s *model.Session, buffer time.Duration) (*model.Session, error) {
+	id, err := nextSequence(ctx, r.coll)
+	if err != nil {
+		return nil, err
+	}
+	s.ID = id
+
+	var conflict *model.Session
+	err = runLocked(ctx, r.coll.Database(), hallLockKey(s.HallID), func(sc mongo.SessionContext) error {
+		var err error
+		if conflict, err = r.findOverlap(sc, s, buffer); err != nil {
+			return err
+		}
+		if conflict != nil {
+			return errHallBusy
+		}
+		_, err = r.coll.InsertOne(sc, s)
+		return err
+	})
+	if errors.Is(err, errHallBusy) {
+		return conflict, nil
+	}
+	return nil, err
+}
+
+// UpdateIfFree обновляет сеанс с той же проверкой пересечений, что и CreateIfFree
+// (сам сеанс из проверки исключается). found == false, если сеанса нет.
+func (r *SessionRepo) UpdateIfFree(ctx context.Context, s *model.Session, buffer time.Duration) (conflict *model.Session, found bool, err error) {
+	err = runLocked(ctx, r.coll.Database(), hallLockKey(s.HallID), func(sc mongo.SessionContext) error {
+		// Сначала убеждаемся, что сеанс есть: иначе вместо 404 получился бы
+		// конфликт с чужим сеансом.
+		n, err := r.coll.CountDocuments(sc, bson.D{{Key: "id", Value: s.ID}})
+		if err != nil || n == 0 {
+			return err
+		}
+		if conflict, err = r.findOverlap(sc, s, buffer); err != nil {
+			return err
+		}
+		if conflict != nil {
+			return errHallBusy
+		}
+		found, err = r.Update(sc, s)
+		return err
+	})
+	if errors.Is(err, errHallBusy) {
+		return conflict, true, nil
+	}
+	return nil, found, err
+}
+
+// findOverlap ищет в зале сеанса s другой сеанс, пересекающийся с ним с учётом
+// буфера на уборку между показами.
+func (r *SessionRepo) findOverlap(ctx context.Context, s *model.Session, buffer time.Duration) (*model.Session, error) {
+	filter := bson.D{
+		{Key: "hall_id", Value: s.HallID},
+		{Key: "id", Value: bson.D{{Key: "$ne", Value: s.ID}}},
+		{Key: "start_time", Value: bson.D{{Key: "$lt", Value: s.EndTime.Add(buffer)}}},
+		{Key: "end_time", Value: bson.D{{Key: "$gt", Value: s.StartTime.Add(-buffer)}}},
+	}
+	var other model.Session
+	err := r.coll.FindOne(ctx, filter, options.FindOne().SetSort(bson.D{{Key: "start_time", Value: 1}})).Decode(&other)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &other, nil
+}
+
+func hallLockKey(hallID int) string {
+	return fmt.Sprintf("hall:%d", hallID)
+}
+
+// GetByID возвращает сеанс по id или nil, если не найден.
+func (r *SessionRepo) GetByID(ctx context.Context, id int) (*model.Session, error) {
+	var s model.Session
+	err := r.coll.FindOne(ctx, bson.D{{Key: "id", Value: id}}).Decode(&s)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Find возвращает сеансы по фильтру, отсортированные по времени начала.
+func (r *SessionRepo) Find(ctx context.Context, f SessionFilter) ([]*model.Session, error) {
+	filter := bson.D{}
+	if f.MovieID != 0 {
+		filter = append(filter, bson.E{Key: "movie_id", Value: f.MovieID})
+	}
+	if f.HallID != 0 {
+		filter = append(filter, bson.E{Key: "hall_id", Value: f.HallID})
+	}
+	start := bson.D{}
+	if !f.From.IsZero() {
+		start = append(start, bson.E{Key: "$gte", Value: f.From})
+	}
+	if !f.To.IsZero() {
+		start = append(start, bson.E{Key: "$lt", Value: f.To})
+	}
+	if len(start) > 0 {
+		filter = append(filter, bson.E{Key: "start_time", Value: start})
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "start_time", Value: 1}, {Key: "id", Value: 1}})
+	cur, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var out []*model.Session
+	for cur.Next(ctx) {
+		var s model.Session
+		if err := cur.Decode(&s); err != nil {
+			return nil, err
+		}
+		out = append(out, &s)
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CountByHall считает сеансы в зале.
+func (r *SessionRepo) CountByHall(ctx context.Context, hallID int) (int64, error) {
+	return r.coll.CountDocuments(ctx, bson.D{{Key: "hall_id", Value: hallID}})
+}
+
+// Update обновляет сеанс. Возвращает false, если сеанс не найден.
+func (r *SessionRepo) Update(ctx context.Context, s *model.Session) (bool, error) {
+	update := bson.D{{
+		Key: "$set",
+		Value: bson.D{
+			{Key: "movie_id", Value: s.MovieID},
+			{Key: "hall_id", Value: s.HallID},
+			{Key: "start_time", Value: s.StartTime},
+			{Key: "end_time", Value: s.EndTime},
+			{Key: "language", Value: s.Language},
+			{Key: "format", Value: s.Format},
+			{Key: "base_price", Value: s.BasePrice},
+		},
+	}}
+	res, err := r.coll.UpdateOne(ctx, bson.D{{Key: "id", Value: s.ID}}, update)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// Delete удаляет сеанс по id.
+func (r *SessionRepo) Delete(ctx context.Context, id int) error {
+	_, err := r.coll.DeleteOne(ctx, bson.D{{Key: "id", Value: id}})
+	return err
+}
@@ -5,8 +5,12 @@ import (
 	"cinema-system/model"
 
 	"errors"
+	"fmt"
+	"regexp"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -48,6 +52,19 @@ func (r *UserRepo) Create(ctx context.Context, u *model.User) (*model.User, erro
 	return u, nil
 }
 
+// GetByID возвращает пользователя по id или nil, если не найден.
+func (r *UserRepo) GetByID(ctx context.Context, id int) (*model.User, error) {
+	var u model.User
+	err := r.coll.FindOne(ctx, bson.D{{Key: "id", Value: id}}).Decode(&u)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
 // GetByEmail возвращает пользователя по email или nil, если не найден.
 func (r *UserRepo) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	var u model.User
@@ -61,8 +78,227 @@ func (r *UserRepo) GetByEmail(ctx context.Context, email string) (*model.User, e
 	return &u, nil
 }
 
+// SetPasswordHash заменяет хеш пароля пользователя. mustChange требует
+// сменить пароль при следующем входе.
+func (r *UserRepo) SetPasswordHash(ctx context.Context, id int, hash string, mustChange bool) error {
+	_, err := r.coll.UpdateOne(ctx, bson.D{{Key: "id", Value: id}},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "password_hash", Value: hash},
+			{Key: "must_change_password", Value: mustChange},
+		}}})
+	return err
+}
+
+// MarkEmailVerified отмечает email подтверждённым, если он не изменился с
+// момента отправки письма. Возвращает false, если адрес уже другой.
+func (r *UserRepo) MarkEmailVerified(ctx context.Context, id int, email string) (bool, error) {
+	res, err := r.coll.UpdateOne(ctx,
+		bson.D{{Key: "id", Value: id}, {Key: "email", Value: email}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "email_verified", Value: true}}}})
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
 // CountByRole считает пользователей с указанной ролью.
 func (r *UserRepo) CountByRole(ctx context.Context, role string) (int64, error) {
 	return r.coll.CountDocuments(ctx, bson.D{{Key: "role", Value: role}})
 }
 
+// CountEnabledByRole считает не отключённых пользователей с указанной ролью.
+func (r *UserRepo) CountEnabledByRole(ctx context.Context, role string) (int64, error) {
+	return r.coll.CountDocuments(ctx, bson.D{{Key: "role", Value: role}, {Key: "disabled", Value: bson.D{{Key: "$ne", Value: true}}}})
+}
+
+// UserFilter — отбор пользователей для списка; нулевые поля не фильтруют.
+type UserFilter struct {
+	Query    string // подстрока email или имени, без учёта регистра
+	Role     string
+	Disabled *bool
+	Skip     int
+	Limit    int
+}
+
+// Find возвращает страницу пользователей по id и общее число подходящих.
+func (r *UserRepo) Find(ctx context.Context, f UserFilter) ([]*model.User, int64, error) {
+	filter := bson.D{}
+	if f.Query != "" {
+		re := primitive.Regex{Pattern: regexp.QuoteMeta(f.Query), Options: "i"}
+		filter = append(filter, bson.E{Key: "$or", Value: bson.A{
+			bson.D{{Key: "email", Value: re}},
+			bson.D{{Key: "name", Value: re}},
+		}})
+	}
+	if f.Role != "" {
+		filter = append(filter, bson.E{Key: "role", Value: f.Role})
+	}
+	if f.Disabled != nil {
+		if *f.Disabled {
+			filter = append(filter, bson.E{Key: "disabled", Value: true})
+		} else {
+			// У старых записей поля нет — они активны.
+			filter = append(filter, bson.E{Key: "disabled", Value: bson.D{{Key: "$ne", Value: true}}})
+		}
+	}
+	total, err := r.coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "id", Value: 1}}).SetSkip(int64(f.Skip))
+	if f.Limit > 0 {
+		opts.SetLimit(int64(f.Limit))
+	}
+	cur, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cur.Close(ctx)
+
+	var out []*model.User
+	for cur.Next(ctx) {
+		var u model.User
+		if err := cur.Decode(&u); err != nil {
+			return nil, 0, err
+		}
+		out = append(out, &u)
+	}
+	return out, total, cur.Err()
+}
+
+// SetRole меняет роль пользователя. Возвращает false, если пользователя нет.
+func (r *UserRepo) SetRole(ctx context.Context, id int, role string) (bool, error) {
+	return r.set(ctx, id, bson.D{{Key: "role", Value: role}})
+}
+
+// SetDisabled отключает или включает учётную запись.
+func (r *UserRepo) SetDisabled(ctx context.Context, id int, disabled bool) (bool, error) {
+	return r.set(ctx, id, bson.D{{Key: "disabled", Value: disabled}})
+}
+
+func (r *UserRepo) set(ctx context.Context, id int, fields bson.D) (bool, error) {
+	res, err := r.coll.UpdateOne(ctx, bson.D{{Key: "id", Value: id}}, bson.D{{Key: "$set", Value: fields}})
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// SetTOTPPending сохраняет выданный, но ещё не подтверждённый секрет TOTP.
+func (r *UserRepo) SetTOTPPending(ctx context.Context, id int, secret string) error {
+	_, err := r.set(ctx, id, bson.D{{Key: "totp_pending_secret", Value: secret}})
+	return err
+}
+
+// EnableTOTP включает второй фактор с подтверждённым секретом. step — интервал
+// кода, которым подтвердили: повторно он не принимается.
+func (r *UserRepo) EnableTOTP(ctx context.Context, id int, secret string, step int64, recoveryCodes []string) error {
+	_, err := r.coll.UpdateOne(ctx, bson.D{{Key: "id", Value: id}}, bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "totp_enabled", Value: true},
+			{Key: "totp_secret", Value: secret},
+			{Key: "totp_last_step", Value: step},
+			{Key: "recovery_codes", Value: recoveryCodes},
+		}},
+		{Key: "$unset", Value: bson.D{{Key: "totp_pending_secret", Value: ""}}},
+	})
+	return err
+}
+
+// DisableTOTP выключает второй фактор и удаляет секреты.
+func (r *UserRepo) DisableTOTP(ctx context.Context, id int) error {
+	_, err := r.coll.UpdateOne(ctx, bson.D{{Key: "id", Value: id}}, bson.D{
+		{Key: "$set", Value: bson.D{{Key: "totp_enabled", Value: false}}},
+		{Key: "$unset", Value: bson.D{
+			{Key: "totp_secret", Value: ""},
+			{Key: "totp_pending_secret", Value: ""},
+			{Key: "totp_last_step", Value: ""},
+			{Key: "recovery_codes", Value: ""},
+		}},
+	})
+	return err
+}
+
+// AdvanceTOTPStep атомарно запоминает принятый интервал кода. Возвращает
+// false, если этот или более поздний интервал уже использован.
+func (r *UserRepo) AdvanceTOTPStep(ctx context.Context, id int, step int64) (bool, error) {
+	res, err := r.coll.UpdateOne(ctx,
+		bson.D{{Key: "id", Value: id}, {Key: "$or", Value: bson.A{
+			bson.D{{Key: "totp_last_step", Value: bson.D{{Key: "$lt", Value: step}}}},
+			bson.D{{Key: "totp_last_step", Value: bson.D{{Key: "$exists", Value: false}}}},
+		}}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "totp_last_step", Value: step}}}})
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// SetRecoveryCodes заменяет коды восстановления (хеши).
+func (r *UserRepo) SetRecoveryCodes(ctx context.Context, id int, codes []string) error {
+	_, err := r.set(ctx, id, bson.D{{Key: "recovery_codes", Value: codes}})
+	return err
+}
+
+// UseRecoveryCode атомарно вычёркивает код восстановления. Возвращает false,
+// если такого неиспользованного кода нет.
+func (r *UserRepo) UseRecoveryCode(ctx context.Context, id int, hash string) (bool, error) {
+	res, err := r.coll.UpdateOne(ctx,
+		bson.D{{Key: "id", Value: id}, {Key: "recovery_codes", Value: hash}},
+		bson.D{{Key: "$pull", Value: bson.D{{Key: "recovery_codes", Value: hash}}}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// UpdateProfile сохраняет имя и язык пользователя.
+func (r *UserRepo) UpdateProfile(ctx context.Context, id int, name, language string) (bool, error) {
+	return r.set(ctx, id, bson.D{{Key: "name", Value: name}, {Key: "language", Value: language}})
+}
+
+// SetPendingEmail запоминает новый адрес до подтверждения по ссылке.
+func (r *UserRepo) SetPendingEmail(ctx context.Context, id int, email string) error {
+	_, err := r.set(ctx, id, bson.D{{Key: "pending_email", Value: email}})
+	return err
+}
+
+// ConfirmEmailChange заменяет email на ожидающий подтверждения, если он всё ещё
+// равен email. Возвращает false, если смену успели отменить или запросить другую.
+func (r *UserRepo) ConfirmEmailChange(ctx context.Context, id int, email string) (bool, error) {
+	res, err := r.coll.UpdateOne(ctx,
+		bson.D{{Key: "id", Value: id}, {Key: "pending_email", Value: email}},
+		bson.D{
+			{Key: "$set", Value: bson.D{{Key: "email", Value: email}, {Key: "email_verified", Value: true}}},
+			{Key: "$unset", Value: bson.D{{Key: "pending_email", Value: ""}}},
+		})
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// Anonymize стирает личные данные удалённой учётной записи. Сама запись
+// остаётся: на неё ссылаются бронирования и журнал аудита.
+func (r *UserRepo) Anonymize(ctx context.Context, id int, at time.Time) error {
+	_, err := r.coll.UpdateOne(ctx, bson.D{{Key: "id", Value: id}}, bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "email", Value: fmt.Sprintf("deleted-%d@deleted.invalid", id)},
+			{Key: "name", Value: ""},
+			{Key: "password_hash", Value: ""},
+			{Key: "email_verified", Value: false},
+			{Key: "disabled", Value: true},
+			{Key: "totp_enabled", Value: false},
+			{Key: "deleted_at", Value: at},
+		}},
+		{Key: "$unset", Value: bson.D{
+			{Key: "pending_email", Value: ""},
+			{Key: "language", Value: ""},
+			{Key: "totp_secret", Value: ""},
+			{Key: "totp_pending_secret", Value: ""},
+			{Key: "totp_last_step", Value: ""},
+			{Key: "recovery_codes", Value: ""},
+		}},
+	})
+	return err
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"cinema-system/model"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UserTokenRepo — одноразовые токены из писем.
+type UserTokenRepo struct {
+	coll *mongo.Collection
+}
+
+// NewUserTokenRepo создаёт коллекцию user_tokens; истёкшие токены удаляет
+// TTL-индекс.
+func NewUserTokenRepo(ctx context.Context, client *mongo.Client, dbName string) (*UserTokenRepo, error) {
+	coll := client.Database(dbName).Collection("user_tokens")
+	_, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "purpose", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &UserTokenRepo{coll: coll}, nil
+}
+
+// Create сохраняет токен.
+func (r *UserTokenRepo) Create(ctx context.Context, t *model.UserToken) error {
+	_, err := r.coll.InsertOne(ctx, t)
+	return err
+}
+
+// Consume атомарно отмечает токен использованным и возвращает его. Если токен
+// не найден, истёк или уже использован, возвращает nil.
+func (r *UserTokenRepo) Consume(ctx context.Context, hash, purpose string, now time.Time) (*model.UserToken, error) {
+	filter := bson.D{
+		{Key: "hash", Value: hash},
+		{Key: "purpose", Value: purpose},
+		{Key: "used_at", Value: nil},
+		{Key: "expires_at", Value: bson.D{{Key: "$gt", Value: now}}},
+	}
+	var t model.UserToken
+	err := r.coll.FindOneAndUpdate(ctx, filter,
+		bson.D{{Key: "$set", Value: bson.D{{Key: "used_at", Value: now}}}},
+	).Decode(&t)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// InvalidateForUser гасит все неиспользованные токены пользователя с этим назначением.
+func (r *UserTokenRepo) InvalidateForUser(ctx context.Context, userID int, purpose string, now time.Time) error {
+	_, err := r.coll.UpdateMany(ctx,
+		bson.D{{Key: "user_id", Value: userID}, {Key: "purpose", Value: purpose}, {Key: "used_at", Value: nil}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "used_at", Value: now}}}})
+	return err
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"cinema-system/mail"
+	"cinema-system/model"
+	"cinema-system/repository"
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MinPasswordLength — минимальная длина нового пароля.
+const MinPasswordLength = 8
+
+// Сроки действия токенов из писем.
+const (
+	verifyEmailTTL   = 48 * time.Hour
+	resetPasswordTTL = time.Hour
+)
+
+// AccountService — подтверждение email и восстановление пароля по письмам.
+type AccountService struct {
+	users   *repository.UserRepo
+	tokens  *repository.UserTokenRepo
+	logins  *TokenService
+	audit   *AuditService
+	mailer  mail.Mailer
+	baseURL string
+}
+
+// NewAccountService создаёт сервис. baseURL — адрес сайта для ссылок в письмах.
+func NewAccountService(users *repository.UserRepo, tokens *repository.UserTokenRepo, logins *TokenService, audit *AuditService, mailer mail.Mailer, baseURL string) *AccountService {
+	return &AccountService{users: users, tokens: tokens, logins: logins, audit: audit, mailer: mailer, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// SendVerification отправляет письмо со ссылкой подтверждения адреса u.Email.
+func (s *AccountService) SendVerification(ctx context.Context, u *model.User) error {
+	token, err := s.issue(ctx, u, model.TokenVerifyEmail, verifyEmailTTL)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, mail.Message{
+		To:      u.Email,
+		Subject: "Подтвердите email — Cinema System",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\nЧтобы подтвердить адрес, откройте ссылку:\n%s\n\nСсылка действует 48 часов. Если вы не регистрировались в Cinema System, просто проигнорируйте письмо.\n",
+			displayName(u), s.link("verify", token)),
+	})
+}
+
+// SendEmailChange отправляет ссылку подтверждения на новый адрес, а на
+// старый — уведомление о том, что адрес меняют.
+func (s *AccountService) SendEmailChange(ctx context.Context, u *model.User, newEmail string) error {
+	now := time.Now().UTC()
+	// Действует только ссылка на последний запрошенный адрес.
+	if err := s.tokens.InvalidateForUser(ctx, u.ID, model.TokenVerifyEmail, now); err != nil {
+		return err
+	}
+	target := *u
+	target.Email = newEmail
+	token, err := s.issue(ctx, &target, model.TokenVerifyEmail, verifyEmailTTL)
+	if err != nil {
+		return err
+	}
+	if err := s.mailer.Send(ctx, mail.Message{
+		To:      newEmail,
+		Subject: "Подтвердите новый email — Cinema System",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\nЧтобы сменить адрес учётной записи на %s, откройте ссылку:\n%s\n\nСсылка действует 48 часов. До подтверждения вход выполняется по прежнему адресу.\n",
+			displayName(u), newEmail, s.link("verify", token)),
+	}); err != nil {
+		return err
+	}
+	if err := s.mailer.Send(ctx, mail.Message{
+		To:      u.Email,
+		Subject: "Смена email — Cinema System",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\nДля вашей учётной записи запрошена смена адреса на %s. Если это были не вы, смените пароль.\n",
+			displayName(u), newEmail),
+	}); err != nil {
+		log.Printf("[mail] failed to notify user %d about email change: %v", u.ID, err)
+	}
+	return nil
+}
+
+// Verify подтверждает email по токену из письма. Если токен выдан на новый
+// адрес (смена email), адрес учётной записи заменяется им.
+func (s *AccountService) Verify(ctx context.Context, token string) (*model.User, error) {
+	t, err := s.tokens.Consume(ctx, hashToken(token), model.TokenVerifyEmail, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, invalidf("verification link is invalid or expired")
+	}
+	ok, err := s.users.MarkEmailVerified(ctx, t.UserID, t.Email)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		ok, err = s.confirmEmailChange(ctx, t.UserID, t.Email)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !ok {
+		// Адрес успели сменить после отправки письма.
+		return nil, invalidf("verification link is invalid or expired")
+	}
+	return s.users.GetByID(ctx, t.UserID)
+}
+
+// Forgot отправляет письмо со ссылкой сброса пароля. Ответ не зависит от
+// того, есть ли такой пользователь, чтобы по нему нельзя было перебирать адреса.
+func (s *AccountService) Forgot(ctx context.Context, email string) error {
+	u, err := s.users.GetByEmail(ctx, strings.TrimSpace(email))
+	if err != nil || u == nil || u.Disabled {
+		return err
+	}
+	// Действует только последняя ссылка.
+	if err := s.tokens.InvalidateForUser(ctx, u.ID, model.TokenResetPassword, time.Now().UTC()); err != nil {
+		return err
+	}
+	token, err := s.issue(ctx, u, model.TokenResetPassword, resetPasswordTTL)
+	if err != nil {
+		return err
+	}
+	if err := s.mailer.Send(ctx, mail.Message{
+		To:      u.Email,
+		Subject: "Сброс пароля — Cinema System",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\nЧтобы задать новый пароль, откройте ссылку:\n%s\n\nСсылка действует 1 час и срабатывает один раз. Если вы не запрашивали сброс, просто проигнорируйте письмо — пароль останется прежним.\n",
+			displayName(u), s.link("reset", token)),
+	}); err != nil {
+		// Сбой почты не раскрываем клиенту: ответ должен быть одинаковым.
+		log.Printf("[mail] failed to send password reset to user %d: %v", u.ID, err)
+	}
+	return nil
+}
+
+// Reset задаёт новый пароль по токену из письма. Все сессии пользователя после
+// этого завершаются.
+func (s *AccountService) Reset(ctx context.Context, token, password string) error {
+	if len(password) < MinPasswordLength {
+		return invalidf("password must be at least %d characters", MinPasswordLength)
+	}
+	now := time.Now().UTC()
+	t, err := s.tokens.Consume(ctx, hashToken(token), model.TokenResetPassword, now)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return invalidf("reset link is invalid or expired")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := s.users.SetPasswordHash(ctx, t.UserID, string(hash), false); err != nil {
+		return err
+	}
+	if err := s.tokens.InvalidateForUser(ctx, t.UserID, model.TokenResetPassword, now); err != nil {
+		return err
+	}
+	if _, err := s.logins.endSessions(ctx, t.UserID); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, Actor{UserID: t.UserID}, model.AuditPasswordReset, "user", t.UserID, nil)
+	return nil
+}
+
+// confirmEmailChange переводит учётную запись на подтверждённый новый адрес,
+// если за время ожидания его не занял другой пользователь.
+func (s *AccountService) confirmEmailChange(ctx context.Context, userID int, email string) (bool, error) {
+	owner, err := s.users.GetByEmail(ctx, email)
+	if err != nil {
+		return false, err
+	}
+	if owner != nil && owner.ID != userID {
+		return false, &ConflictError{Msg: "email already in use"}
+	}
+	ok, err := s.users.ConfirmEmailChange(ctx, userID, email)
+	if err != nil || !ok {
+		return ok, err
+	}
+	s.audit.Record(ctx, Actor{UserID: userID}, model.AuditEmailChanged, "user", userID, map[string]interface{}{"email": email})
+	return true, nil
+}
+
+func (s *AccountService) issue(ctx context.Context, u *model.User, purpose string, ttl time.Duration) (string, error) {
+	token := randomToken(32)
+	now := time.Now().UTC()
+	err := s.tokens.Create(ctx, &model.UserToken{
+		Hash:      hashToken(token),
+		UserID:    u.ID,
+		Purpose:   purpose,
+		Email:     u.Email,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	})
+	return token, err
+}
+
+// link строит ссылку на сайт: страница сама отправит токен в API.
+func (s *AccountService) link(param, token string) string {
+	return s.baseURL + "/?" + param + "=" + url.QueryEscape(token)
+}
+
+func displayName(u *model.User) string {
+	if u.Name != "" {
+		return u.Name
+	}
+	return u.Email
+}
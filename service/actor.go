@@ -0,0 +1,13 @@
+package service
+
+// Actor — пользователь, от имени которого вызывается сервис (берётся из JWT).
+type Actor struct {
+	UserID      int
+	Role        string
+	Permissions []string // права роли на момент запроса
+}
+
+// Can сообщает, есть ли у пользователя право.
+func (a Actor) Can(permission string) bool {
+	return contains(a.Permissions, permission)
+}
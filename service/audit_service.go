@@ -0,0 +1,43 @@
+package service
+
+import (
+	"cinema-system/model"
+	"cinema-system/repository"
+	"context"
+	"log"
+	"time"
+)
+
+// AuditService ведёт журнал аудита.
+type AuditService struct {
+	repo *repository.AuditRepo
+}
+
+func NewAuditService(repo *repository.AuditRepo) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// Record добавляет запись в журнал. Сбой записи не должен отменять уже
+// выполненное действие, поэтому ошибка только логируется.
+func (s *AuditService) Record(ctx context.Context, actor Actor, action, entity string, entityID int, details map[string]interface{}) {
+	_, err := s.repo.Create(ctx, &model.AuditEvent{
+		Action:    action,
+		ActorID:   actor.UserID,
+		ActorRole: actor.Role,
+		Entity:    entity,
+		EntityID:  entityID,
+		Details:   details,
+		At:        time.Now().UTC(),
+	})
+	if err != nil {
+		log.Printf("[audit] failed to record %s on %s %d by user %d: %v", action, entity, entityID, actor.UserID, err)
+	}
+}
+
+// Find возвращает записи журнала (новые первыми), не больше 500 за раз.
+func (s *AuditService) Find(ctx context.Context, f repository.AuditFilter) ([]*model.AuditEvent, error) {
+	if f.Limit <= 0 || f.Limit > 500 {
+		f.Limit = 500
+	}
+	return s.repo.Find(ctx, f)
+}
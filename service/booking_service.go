@@ -0,0 +1,181 @@
+package service
+
+import (
+	"cinema-system/eticket"
+	"cinema-system/model"
+	"cinema-system/realtime"
+	"cinema-system/repository"
+	"context"
+	"errors"
+	"time"
+)
+
+// MaxSeatsPerBooking ограничивает количество мест в одном заказе.
+const MaxSeatsPerBooking = 10
+
+// BookingService — оформление бронирований.
+type BookingService struct {
+	repo     *repository.BookingRepo
+	sessions *repository.SessionRepo
+	movies   *repository.MovieRepo
+	halls    *repository.HallRepo
+	pricing  *PricingService
+	events   realtime.Publisher
+	signer   *eticket.Signer
+}
+
+func NewBookingService(repo *repository.BookingRepo, sessions *repository.SessionRepo, movies *repository.MovieRepo, halls *repository.HallRepo, pricing *PricingService, events realtime.Publisher, signer *eticket.Signer) *BookingService {
+	return &BookingService{repo: repo, sessions: sessions, movies: movies, halls: halls, pricing: pricing, events: events, signer: signer}
+}
+
+// TicketRequest — место и тип билета, которые выбрал покупатель.
+type TicketRequest struct {
+	Row  string `json:"row"`
+	Seat int    `json:"seat"`
+	Type string `json:"type"`
+}
+
+// Create бронирует места на сеанс. Цены и итоговая сумма всегда считаются на
+// сервере по правилам ценообразования — цена от клиента не принимается. Если
+// хотя бы одно место уже занято, возвращается ConflictError со списком мест.
+//
+// На фильмы 16+/18+ детские билеты не продаются. Кассир, продающий билеты на
+// такой сеанс, должен проверить возраст покупателя и подтвердить это флагом
+// ageConfirmed; без него возвращается ConflictError с ageVerificationRequired.
+func (s *BookingService) Create(ctx context.Context, actor Actor, sessionID int, reqs []TicketRequest, ageConfirmed bool) (*model.Booking, error) {
+	if len(reqs) == 0 {
+		return nil, invalidf("at least one seat is required")
+	}
+	if len(reqs) > MaxSeatsPerBooking {
+		return nil, invalidf("at most %d seats per booking", MaxSeatsPerBooking)
+	}
+
+	sess, hall, err := openForSale(ctx, s.sessions, s.halls, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	pricer, err := s.pricing.For(ctx, sess, hall)
+	if err != nil {
+		return nil, err
+	}
+	movie, err := s.movies.GetByID(sess.MovieID)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &model.Booking{
+		UserID:    actor.UserID,
+		SessionID: sess.ID,
+		Status:    model.BookingPending,
+		CreatedAt: time.Now().UTC(),
+	}
+	if movie != nil && !model.MovieOnSale(movie.Status) {
+		return nil, &ConflictError{Msg: "tickets for this movie are not on sale"}
+	}
+	if movie != nil && model.AgeRestricted(movie.AgeRating) {
+		for _, req := range reqs {
+			if req.Type == model.TicketChild {
+				return nil, invalidf("child tickets are not sold for %s movies", movie.AgeRating)
+			}
+		}
+		if actor.Can(model.PermBookingsManage) {
+			if !ageConfirmed {
+				return nil, &ConflictError{Msg: "age verification required", Conflict: map[string]interface{}{
+					"ageVerificationRequired": true,
+					"ageRating":               movie.AgeRating,
+				}}
+			}
+			b.AgeCheckedBy = actor.UserID
+		}
+	}
+	seen := make(map[model.SeatRef]bool, len(reqs))
+	for _, req := range reqs {
+		ref := model.SeatRef{Row: req.Row, Seat: req.Seat}
+		if seen[ref] {
+			return nil, invalidf("seat %s%d is listed twice", req.Row, req.Seat)
+		}
+		seen[ref] = true
+
+		seat, err := saleableSeat(hall, ref)
+		if err != nil {
+			return nil, err
+		}
+		if req.Type == "" {
+			req.Type = model.TicketAdult
+		}
+		price, err := pricer.Price(req.Type, seat.Category)
+		if err != nil {
+			return nil, err
+		}
+		b.Tickets = append(b.Tickets, &model.Ticket{Row: req.Row, Seat: req.Seat, Type: req.Type, Price: price})
+		b.Total += price
+	}
+
+	created, err := s.repo.Create(ctx, b)
+	var taken *repository.SeatsTakenError
+	if errors.As(err, &taken) {
+		return nil, &ConflictError{Msg: "some seats are already taken", Conflict: taken.Seats}
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.events.Publish(realtime.SeatEvent{SessionID: created.SessionID, State: model.SeatStateSold, Seats: ticketSeats(created.Tickets)})
+	return created, nil
+}
+
+func ticketSeats(tickets []*model.Ticket) []model.SeatRef {
+	out := make([]model.SeatRef, 0, len(tickets))
+	for _, t := range tickets {
+		out = append(out, model.SeatRef{Row: t.Row, Seat: t.Seat})
+	}
+	return out
+}
+
+// Get возвращает бронирование владельцу или сотруднику кинотеатра. У билетов
+// оплаченного бронирования заполнены токены для QR-кодов.
+func (s *BookingService) Get(ctx context.Context, actor Actor, id int) (*model.Booking, error) {
+	b, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// Чужое бронирование для покупателя неотличимо от несуществующего.
+	if b == nil || (b.UserID != actor.UserID && !actor.Can(model.PermBookingsManage)) {
+		return nil, ErrNotFound
+	}
+	issueTokens(s.signer, b)
+	return b, nil
+}
+
+// openForSale загружает сеанс и его зал и проверяет, что на сеанс ещё продаются билеты.
+func openForSale(ctx context.Context, sessions *repository.SessionRepo, halls *repository.HallRepo, sessionID int) (*model.Session, *model.Hall, error) {
+	sess, err := sessions.GetByID(ctx, sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if sess == nil {
+		return nil, nil, invalidf("session %d does not exist", sessionID)
+	}
+	if !sess.StartTime.After(time.Now()) {
+		return nil, nil, invalidf("session has already started")
+	}
+	hall, err := halls.GetByID(ctx, sess.HallID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if hall == nil {
+		return nil, nil, invalidf("hall %d does not exist", sess.HallID)
+	}
+	return sess, hall, nil
+}
+
+// saleableSeat проверяет, что место есть в схеме зала и не выведено из продажи.
+func saleableSeat(hall *model.Hall, ref model.SeatRef) (*model.Seat, error) {
+	seat := hall.FindSeat(ref.Row, ref.Seat)
+	if seat == nil {
+		return nil, invalidf("seat %s%d does not exist in %s", ref.Row, ref.Seat, hall.Name)
+	}
+	if seat.Blocked {
+		return nil, invalidf("seat %s%d is not for sale", ref.Row, ref.Seat)
+	}
+	return seat, nil
+}
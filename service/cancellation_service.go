@@ -0,0 +1,161 @@
+package service
+
+import (
+	"cinema-system/model"
+	"cinema-system/realtime"
+	"cinema-system/repository"
+	"context"
+	"log"
+	"strings"
+	"time"
+)
+
+// RefundPolicy определяет, сколько денег вернуть при отмене оплаченного
+// бронирования: всё — не позже чем за FullBefore до начала сеанса, LatePercent
+// процентов — позже, и ничего после начала сеанса.
+type RefundPolicy struct {
+	FullBefore  time.Duration
+	LatePercent int
+}
+
+// DefaultRefundPolicy — полный возврат за 2 часа до сеанса, потом 50%.
+var DefaultRefundPolicy = RefundPolicy{FullBefore: 2 * time.Hour, LatePercent: 50}
+
+// Amount возвращает сумму возврата из paid для сеанса, начинающегося в start.
+func (p RefundPolicy) Amount(paid int, start, now time.Time) int {
+	switch {
+	case !now.Before(start):
+		return 0
+	case start.Sub(now) >= p.FullBefore:
+		return paid
+	default:
+		return paid * p.LatePercent / 100
+	}
+}
+
+// CancellationResult — итог отмены: бронирование и возврат, если он был.
+type CancellationResult struct {
+	Booking *model.Booking `json:"booking"`
+	Refund  *model.Refund  `json:"refund,omitempty"`
+}
+
+// CancellationService отменяет бронирования и возвращает деньги по политике.
+type CancellationService struct {
+	bookings *repository.BookingRepo
+	sessions *repository.SessionRepo
+	payments *PaymentService
+	audit    *AuditService
+	events   realtime.Publisher
+	policy   RefundPolicy
+}
+
+func NewCancellationService(bookings *repository.BookingRepo, sessions *repository.SessionRepo, payments *PaymentService, audit *AuditService, events realtime.Publisher, policy RefundPolicy) *CancellationService {
+	return &CancellationService{bookings: bookings, sessions: sessions, payments: payments, audit: audit, events: events, policy: policy}
+}
+
+// Cancel отменяет бронирование. Покупатель может отменить только своё
+// бронирование, только до начала сеанса и пока ни по одному билету не прошли в
+// зал; сотрудник с правом bookings:refund — любое и в любое время. Места освобождаются сразу, возврат по оплаченному бронированию
+// считается по политике и проводится через платёжного провайдера.
+func (s *CancellationService) Cancel(ctx context.Context, actor Actor, bookingID int, reason string) (*CancellationResult, error) {
+	b, err := s.bookings.GetByID(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil || (b.UserID != actor.UserID && !actor.Can(model.PermBookingsRefund)) {
+		return nil, ErrNotFound
+	}
+	if b.Status != model.BookingPending && b.Status != model.BookingPaid {
+		return nil, &ConflictError{Msg: "booking is " + b.Status + " and cannot be cancelled"}
+	}
+	if b.Status == model.BookingPending {
+		// Вебхук по незавершённому платежу может прийти в любой момент.
+		pending, err := s.payments.InProgress(ctx, b.ID)
+		if err != nil {
+			return nil, err
+		}
+		if pending != nil {
+			return nil, &ConflictError{Msg: "payment is in progress; try again when it completes", Conflict: pending}
+		}
+	}
+	sess, err := s.sessions.GetByID(ctx, b.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if err := checkCancellable(actor, b, sess, now); err != nil {
+		return nil, err
+	}
+
+	refundAmount := 0
+	if b.Status == model.BookingPaid && sess != nil {
+		refundAmount = s.policy.Amount(b.Total, sess.StartTime, now)
+	}
+	reason = strings.TrimSpace(reason)
+	cancelled, err := s.bookings.Cancel(ctx, b.ID, repository.CancelRequest{
+		By:           actor.UserID,
+		Reason:       reason,
+		RefundAmount: refundAmount,
+		At:           now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if cancelled == nil {
+		return nil, &ConflictError{Msg: "booking status changed; reload and try again"}
+	}
+	s.events.Publish(realtime.SeatEvent{SessionID: cancelled.SessionID, State: model.SeatStateFree, Seats: ticketSeats(cancelled.Tickets)})
+
+	details := map[string]interface{}{
+		"previousStatus": b.Status,
+		"refundAmount":   refundAmount,
+	}
+	if reason != "" {
+		details["reason"] = reason
+	}
+	res := &CancellationResult{Booking: cancelled}
+	if refundAmount > 0 {
+		res.Refund, err = s.payments.Refund(ctx, cancelled.ID, refundAmount, "booking cancelled")
+		if err != nil {
+			// Бронирование уже отменено, повтор запроса получит 409. Поэтому
+			// отвечаем как при отказе провайдера, а сбой оставляем в журнале,
+			// чтобы возврат провели вручную.
+			log.Printf("[cancel] refund of booking %d was not recorded: %v", cancelled.ID, err)
+			details["refundError"] = err.Error()
+			res.Refund = &model.Refund{
+				BookingID:     cancelled.ID,
+				Amount:        refundAmount,
+				Status:        model.RefundFailed,
+				Reason:        "booking cancelled",
+				FailureReason: "refund was not recorded",
+				CreatedAt:     now,
+			}
+		}
+	}
+	if res.Refund != nil {
+		if res.Refund.ID != 0 {
+			details["refundId"] = res.Refund.ID
+		}
+		details["refundStatus"] = res.Refund.Status
+	}
+	s.audit.Record(ctx, actor, model.AuditBookingCancelled, "booking", cancelled.ID, details)
+	return res, nil
+}
+
+// checkCancellable проверяет, может ли actor отменить бронирование сам:
+// после начала сеанса или прохода в зал по одному из билетов это делает только
+// сотрудник с правом bookings:refund.
+func checkCancellable(actor Actor, b *model.Booking, sess *model.Session, now time.Time) error {
+	if actor.Can(model.PermBookingsRefund) {
+		return nil
+	}
+	if sess != nil && !now.Before(sess.StartTime) {
+		return &ConflictError{Msg: "session has already started"}
+	}
+	for _, t := range b.Tickets {
+		if t.CheckedInAt != nil {
+			return &ConflictError{Msg: "ticket has already been used to enter the hall"}
+		}
+	}
+	return nil
+}
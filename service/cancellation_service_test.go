@@ -0,0 +1,67 @@
+package service
+
+import (
+	"cinema-system/model"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRefundPolicyAmount(t *testing.T) {
+	p := RefundPolicy{FullBefore: 2 * time.Hour, LatePercent: 50}
+	start := time.Date(2026, 3, 1, 19, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		paid int
+		now  time.Time
+		want int
+	}{
+		{"day before", 3000, start.Add(-24 * time.Hour), 3000},
+		{"exactly at full refund limit", 3000, start.Add(-2 * time.Hour), 3000},
+		{"just after full refund limit", 3000, start.Add(-2*time.Hour + time.Second), 1500},
+		{"minute before start", 3000, start.Add(-time.Minute), 1500},
+		{"late percent rounds down", 2999, start.Add(-time.Hour), 1499},
+		{"at start", 3000, start, 0},
+		{"after start", 3000, start.Add(time.Minute), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Amount(tt.paid, start, tt.now); got != tt.want {
+				t.Errorf("Amount = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckCancellable(t *testing.T) {
+	start := time.Date(2026, 3, 1, 19, 0, 0, 0, time.UTC)
+	sess := &model.Session{ID: 1, StartTime: start}
+	used := start.Add(-10 * time.Minute)
+	fresh := &model.Booking{Tickets: []*model.Ticket{{Row: "A", Seat: 1}, {Row: "A", Seat: 2}}}
+	entered := &model.Booking{Tickets: []*model.Ticket{{Row: "A", Seat: 1}, {Row: "A", Seat: 2, CheckedInAt: &used}}}
+	customer := Actor{UserID: 7, Role: RoleCustomer}
+	staff := Actor{UserID: 1, Role: RoleAdmin, Permissions: []string{model.PermBookingsRefund}}
+
+	tests := []struct {
+		name    string
+		actor   Actor
+		booking *model.Booking
+		now     time.Time
+		wantErr bool
+	}{
+		{"customer before start", customer, fresh, start.Add(-time.Hour), false},
+		{"customer after start", customer, fresh, start, true},
+		{"customer after check-in", customer, entered, start.Add(-5 * time.Minute), true},
+		{"staff after check-in", staff, entered, start.Add(-5 * time.Minute), false},
+		{"staff after start", staff, entered, start.Add(time.Hour), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkCancellable(tt.actor, tt.booking, sess, tt.now)
+			var cerr *ConflictError
+			if tt.wantErr != errors.As(err, &cerr) {
+				t.Errorf("checkCancellable = %v, want conflict %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound возвращается, когда запрошенная сущность не существует.
+var ErrNotFound = errors.New("not found")
+
+// ValidationError — ошибка входных данных; хендлеры отдают её клиенту как 400.
+type ValidationError struct {
+	Msg string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Msg
+}
+
+func invalidf(format string, args ...interface{}) error {
+	return &ValidationError{Msg: fmt.Sprintf(format, args...)}
+}
+
+// ConflictError — операция противоречит текущему состоянию данных (409).
+type ConflictError struct {
+	Msg      string
+	Conflict interface{} // сущность, с которой возник конфликт, если есть
+}
+
+func (e *ConflictError) Error() string {
+	return e.Msg
+}
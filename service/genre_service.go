@@ -0,0 +1,160 @@
+package service
+
+import (
+	"cinema-system/model"
+	"cinema-system/repository"
+	"context"
+	"errors"
+	"strings"
+)
+
+// GenreService — справочник жанров и перевод фильмов со строковых жанров на id.
+type GenreService struct {
+	repo   *repository.GenreRepo
+	movies *repository.MovieRepo
+}
+
+func NewGenreService(repo *repository.GenreRepo, movies *repository.MovieRepo) *GenreService {
+	return &GenreService{repo: repo, movies: movies}
+}
+
+// GetAll возвращает все жанры.
+func (s *GenreService) GetAll(ctx context.Context) ([]*model.Genre, error) {
+	return s.repo.GetAll(ctx)
+}
+
+// GetByID возвращает жанр по id.
+func (s *GenreService) GetByID(ctx context.Context, id int) (*model.Genre, error) {
+	g, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if g == nil {
+		return nil, ErrNotFound
+	}
+	return g, nil
+}
+
+// Create добавляет жанр. Русское название обязательно и уникально.
+func (s *GenreService) Create(ctx context.Context, g *model.Genre) (*model.Genre, error) {
+	if err := normalizeGenre(g); err != nil {
+		return nil, err
+	}
+	created, err := s.repo.Create(ctx, g)
+	if errors.Is(err, repository.ErrGenreExists) {
+		return nil, &ConflictError{Msg: "genre already exists"}
+	}
+	return created, err
+}
+
+// Update меняет названия жанра.
+func (s *GenreService) Update(ctx context.Context, g *model.Genre) error {
+	if err := normalizeGenre(g); err != nil {
+		return err
+	}
+	found, err := s.repo.Update(ctx, g)
+	if errors.Is(err, repository.ErrGenreExists) {
+		return &ConflictError{Msg: "genre already exists"}
+	}
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete удаляет жанр, если на него не ссылается ни один фильм. Несуществующий
+// жанр — ErrNotFound.
+func (s *GenreService) Delete(ctx context.Context, id int) error {
+	if _, err := s.GetByID(ctx, id); err != nil {
+		return err
+	}
+	n, err := s.movies.CountByGenre(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return &ConflictError{Msg: "genre is used by movies"}
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+// Resolve проверяет, что все жанры фильма есть в справочнике, и убирает повторы.
+func (s *GenreService) Resolve(ctx context.Context, ids []int) ([]int, error) {
+	out := make([]int, 0, len(ids))
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	if len(out) == 0 {
+		return out, nil
+	}
+	n, err := s.repo.CountByIDs(ctx, out)
+	if err != nil {
+		return nil, err
+	}
+	if int(n) != len(out) {
+		return nil, invalidf("unknown genre in %v", out)
+	}
+	return out, nil
+}
+
+// MigrateLegacy переводит фильмы со строковым жанром («Драма, Триллер») на
+// id из справочника: строка делится по запятым, незнакомые жанры заводятся с
+// русским названием. Повторный запуск ничего не меняет. Возвращает число
+// переведённых фильмов.
+func (s *GenreService) MigrateLegacy(ctx context.Context) (int, error) {
+	movies, err := s.movies.WithLegacyGenre(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, m := range movies {
+		ids := append([]int{}, m.Genres...)
+		for _, name := range strings.Split(m.LegacyGenre, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			g, err := s.findOrCreate(ctx, name)
+			if err != nil {
+				return 0, err
+			}
+			ids = append(ids, g.ID)
+		}
+		if ids, err = s.Resolve(ctx, ids); err != nil {
+			return 0, err
+		}
+		if err := s.movies.ReplaceLegacyGenre(ctx, m.ID, ids); err != nil {
+			return 0, err
+		}
+	}
+	return len(movies), nil
+}
+
+func (s *GenreService) findOrCreate(ctx context.Context, name string) (*model.Genre, error) {
+	g, err := s.repo.GetByName(ctx, name)
+	if err != nil || g != nil {
+		return g, err
+	}
+	g, err = s.repo.Create(ctx, &model.Genre{Name: model.LocalizedName{RU: name}})
+	if errors.Is(err, repository.ErrGenreExists) {
+		// Жанр успел завести параллельный запуск.
+		return s.repo.GetByName(ctx, name)
+	}
+	return g, err
+}
+
+func normalizeGenre(g *model.Genre) error {
+	g.Name.RU = strings.TrimSpace(g.Name.RU)
+	g.Name.KK = strings.TrimSpace(g.Name.KK)
+	g.Name.EN = strings.TrimSpace(g.Name.EN)
+	if g.Name.RU == "" {
+		return invalidf("genre name in Russian is required")
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"cinema-system/model"
+	"cinema-system/repository"
+	"context"
+	"strings"
+)
+
+// HallService — бизнес-логика залов и схем мест.
+type HallService struct {
+	repo     *repository.HallRepo
+	sessions *repository.SessionRepo
+}
+
+func NewHallService(repo *repository.HallRepo, sessions *repository.SessionRepo) *HallService {
+	return &HallService{repo: repo, sessions: sessions}
+}
+
+// Create проверяет схему и сохраняет новый зал.
+func (s *HallService) Create(ctx context.Context, h *model.Hall) (*model.Hall, error) {
+	if err := normalizeHall(h); err != nil {
+		return nil, err
+	}
+	return s.repo.Create(ctx, h)
+}
+
+// GetByID возвращает зал по id.
+func (s *HallService) GetByID(ctx context.Context, id int) (*model.Hall, error) {
+	h, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if h == nil {
+		return nil, ErrNotFound
+	}
+	return h, nil
+}
+
+// GetAll возвращает все залы.
+func (s *HallService) GetAll(ctx context.Context) ([]*model.Hall, error) {
+	return s.repo.GetAll(ctx)
+}
+
+// Update проверяет схему и обновляет зал.
+func (s *HallService) Update(ctx context.Context, h *model.Hall) error {
+	if err := normalizeHall(h); err != nil {
+		return err
+	}
+	found, err := s.repo.Update(ctx, h)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete удаляет зал, если в нём нет запланированных сеансов. Несуществующий
+// зал — ErrNotFound.
+func (s *HallService) Delete(ctx context.Context, id int) error {
+	if _, err := s.GetByID(ctx, id); err != nil {
+		return err
+	}
+	n, err := s.sessions.CountByHall(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return &ConflictError{Msg: "hall has scheduled sessions"}
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+// normalizeHall проверяет схему зала и проставляет категорию по умолчанию.
+func normalizeHall(h *model.Hall) error {
+	h.Name = strings.TrimSpace(h.Name)
+	if h.Name == "" {
+		return invalidf("hall name is required")
+	}
+	h.Format = strings.ToLower(strings.TrimSpace(h.Format))
+	if h.Format == "" {
+		h.Format = model.HallFormatStandard
+	}
+	if len(h.Rows) == 0 {
+		return invalidf("hall must have at least one row")
+	}
+
+	labels := make(map[string]bool, len(h.Rows))
+	for i := range h.Rows {
+		row := &h.Rows[i]
+		row.Label = strings.TrimSpace(row.Label)
+		if row.Label == "" {
+			return invalidf("row %d has no label", i+1)
+		}
+		if labels[row.Label] {
+			return invalidf("duplicate row label %q", row.Label)
+		}
+		labels[row.Label] = true
+
+		numbers := make(map[int]bool, len(row.Seats))
+		seats := 0
+		for j := range row.Seats {
+			seat := &row.Seats[j]
+			switch seat.Kind {
+			case model.SeatKindSeat:
+				if seat.Number <= 0 {
+					return invalidf("row %s: seat number must be positive", row.Label)
+				}
+				if numbers[seat.Number] {
+					return invalidf("row %s: duplicate seat number %d", row.Label, seat.Number)
+				}
+				numbers[seat.Number] = true
+				if seat.Category == "" {
+					seat.Category = model.SeatCategoryStandard
+				}
+				if !validSeatCategory(seat.Category) {
+					return invalidf("row %s seat %d: unknown category %q", row.Label, seat.Number, seat.Category)
+				}
+				seats++
+			case model.SeatKindAisle, model.SeatKindGap:
+				seat.Number = 0
+				seat.Category = ""
+				seat.Blocked = false
+			default:
+				return invalidf("row %s: unknown cell kind %q", row.Label, seat.Kind)
+			}
+		}
+		if seats == 0 {
+			return invalidf("row %s has no seats", row.Label)
+		}
+	}
+	return nil
+}
+
+func validSeatCategory(c string) bool {
+	switch c {
+	case model.SeatCategoryStandard, model.SeatCategoryVIP, model.SeatCategoryLoveSeat, model.SeatCategoryAccessible:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"cinema-system/model"
+	"errors"
+	"testing"
+)
+
+func seat(n int) model.Seat {
+	return model.Seat{Kind: model.SeatKindSeat, Number: n}
+}
+
+func TestNormalizeHall(t *testing.T) {
+	h := &model.Hall{
+		Name:   "  Зал 1 ",
+		Format: " IMAX ",
+		Rows: []model.SeatRow{{
+			Label: " A ",
+			Seats: []model.Seat{
+				seat(1),
+				{Kind: model.SeatKindAisle, Number: 5, Category: model.SeatCategoryVIP, Blocked: true},
+				{Kind: model.SeatKindSeat, Number: 2, Category: model.SeatCategoryVIP},
+			},
+		}},
+	}
+	if err := normalizeHall(h); err != nil {
+		t.Fatalf("normalizeHall: %v", err)
+	}
+	if h.Name != "Зал 1" || h.Format != "imax" || h.Rows[0].Label != "A" {
+		t.Errorf("name, format, label = %q, %q, %q", h.Name, h.Format, h.Rows[0].Label)
+	}
+	if got := h.Rows[0].Seats[0].Category; got != model.SeatCategoryStandard {
+		t.Errorf("default category = %q, want %q", got, model.SeatCategoryStandard)
+	}
+	if aisle := h.Rows[0].Seats[1]; aisle != (model.Seat{Kind: model.SeatKindAisle}) {
+		t.Errorf("aisle not cleared: %+v", aisle)
+	}
+	if got := h.Rows[0].Seats[2].Category; got != model.SeatCategoryVIP {
+		t.Errorf("category = %q, want %q", got, model.SeatCategoryVIP)
+	}
+
+	empty := &model.Hall{Name: "Зал 2", Rows: []model.SeatRow{{Label: "A", Seats: []model.Seat{seat(1)}}}}
+	if err := normalizeHall(empty); err != nil || empty.Format != model.HallFormatStandard {
+		t.Errorf("default format = %q, err %v", empty.Format, err)
+	}
+}
+
+func TestNormalizeHallRejects(t *testing.T) {
+	row := func(label string, seats ...model.Seat) model.SeatRow {
+		return model.SeatRow{Label: label, Seats: seats}
+	}
+	tests := []struct {
+		name string
+		hall model.Hall
+	}{
+		{"no name", model.Hall{Name: " ", Rows: []model.SeatRow{row("A", seat(1))}}},
+		{"no rows", model.Hall{Name: "Зал"}},
+		{"row without label", model.Hall{Name: "Зал", Rows: []model.SeatRow{row(" ", seat(1))}}},
+		{"duplicate row label", model.Hall{Name: "Зал", Rows: []model.SeatRow{row("A", seat(1)), row("A", seat(1))}}},
+		{"seat number zero", model.Hall{Name: "Зал", Rows: []model.SeatRow{row("A", seat(0))}}},
+		{"duplicate seat number", model.Hall{Name: "Зал", Rows: []model.SeatRow{row("A", seat(1), seat(1))}}},
+		{"unknown category", model.Hall{Name: "Зал", Rows: []model.SeatRow{row("A", model.Seat{Kind: model.SeatKindSeat, Number: 1, Category: "sofa"})}}},
+		{"unknown cell kind", model.Hall{Name: "Зал", Rows: []model.SeatRow{row("A", seat(1), model.Seat{Kind: "stage"})}}},
+		{"row of aisles only", model.Hall{Name: "Зал", Rows: []model.SeatRow{row("A", model.Seat{Kind: model.SeatKindAisle})}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var verr *ValidationError
+			if err := normalizeHall(&tt.hall); !errors.As(err, &verr) {
+				t.Errorf("normalizeHall error = %v, want ValidationError", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"cinema-system/model"
+	"cinema-system/realtime"
+	"cinema-system/repository"
+	"context"
+	"errors"
+	"time"
+)
+
+// HoldService — временное удержание мест между выбором и оплатой.
+type HoldService struct {
+	repo     *repository.HoldRepo
+	sessions *repository.SessionRepo
+	halls    *repository.HallRepo
+	bookings *BookingService
+	events   realtime.Publisher
+	ttl      time.Duration
+}
+
+// NewHoldService создаёт сервис удержаний; ttl — сколько держатся места.
+func NewHoldService(repo *repository.HoldRepo, sessions *repository.SessionRepo, halls *repository.HallRepo, bookings *BookingService, events realtime.Publisher, ttl time.Duration) *HoldService {
+	return &HoldService{repo: repo, sessions: sessions, halls: halls, bookings: bookings, events: events, ttl: ttl}
+}
+
+// Create удерживает места на сеанс за пользователем. Предыдущее удержание этого
+// пользователя на тот же сеанс заменяется.
+func (s *HoldService) Create(ctx context.Context, actor Actor, sessionID int, seats []model.SeatRef) (*model.Hold, error) {
+	if len(seats) == 0 {
+		return nil, invalidf("at least one seat is required")
+	}
+	if len(seats) > MaxSeatsPerBooking {
+		return nil, invalidf("at most %d seats per booking", MaxSeatsPerBooking)
+	}
+	_, hall, err := openForSale(ctx, s.sessions, s.halls, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[model.SeatRef]bool, len(seats))
+	for _, ref := range seats {
+		if seen[ref] {
+			return nil, invalidf("seat %s%d is listed twice", ref.Row, ref.Seat)
+		}
+		seen[ref] = true
+		if _, err := saleableSeat(hall, ref); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now().UTC()
+	h, released, err := s.repo.Create(ctx, &model.Hold{
+		SessionID: sessionID,
+		UserID:    actor.UserID,
+		Seats:     seats,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.ttl),
+	})
+	var taken *repository.SeatsTakenError
+	if errors.As(err, &taken) {
+		return nil, &ConflictError{Msg: "some seats are already taken", Conflict: taken.Seats}
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.events.Publish(realtime.SeatEvent{SessionID: sessionID, State: model.SeatStateFree, Seats: released})
+	s.events.Publish(realtime.SeatEvent{SessionID: sessionID, State: model.SeatStateHeld, Seats: h.Seats})
+	return h, nil
+}
+
+// Get возвращает действующее удержание его владельцу.
+func (s *HoldService) Get(ctx context.Context, actor Actor, id int) (*model.Hold, error) {
+	h, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if h == nil || h.UserID != actor.UserID || !h.ExpiresAt.After(time.Now()) {
+		return nil, ErrNotFound
+	}
+	return h, nil
+}
+
+// Release досрочно освобождает места.
+func (s *HoldService) Release(ctx context.Context, actor Actor, id int) error {
+	h, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if h == nil || (h.UserID != actor.UserID && !actor.Can(model.PermBookingsManage)) {
+		return ErrNotFound
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.events.Publish(realtime.SeatEvent{SessionID: h.SessionID, State: model.SeatStateFree, Seats: h.Seats})
+	return nil
+}
+
+// Convert оформляет бронирование на удержанные места. types задаёт тип билета
+// для отдельных мест (по умолчанию взрослый). Удержание снимается вместе с
+// созданием бронирования. ageConfirmed — как в BookingService.Create.
+func (s *HoldService) Convert(ctx context.Context, actor Actor, id int, types []TicketRequest, ageConfirmed bool) (*model.Booking, error) {
+	h, err := s.Get(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+	typeOf := make(map[model.SeatRef]string, len(types))
+	for _, t := range types {
+		typeOf[model.SeatRef{Row: t.Row, Seat: t.Seat}] = t.Type
+	}
+	reqs := make([]TicketRequest, 0, len(h.Seats))
+	for _, ref := range h.Seats {
+		reqs = append(reqs, TicketRequest{Row: ref.Row, Seat: ref.Seat, Type: typeOf[ref]})
+	}
+	return s.bookings.Create(ctx, actor, h.SessionID, reqs, ageConfirmed)
+}
+
+// ReleaseExpired снимает просроченные удержания; вызывается фоновой задачей.
+func (s *HoldService) ReleaseExpired(ctx context.Context) ([]*model.Hold, error) {
+	expired, err := s.repo.DeleteExpired(ctx, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range expired {
+		s.events.Publish(realtime.SeatEvent{SessionID: h.SessionID, State: model.SeatStateFree, Seats: h.Seats})
+	}
+	return expired, nil
+}
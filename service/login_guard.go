@@ -0,0 +1,170 @@
+package service
+
+import (
+	"cinema-system/model"
+	"cinema-system/repository"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LoginPolicy — ограничение неудачных входов для одного ключа (учётной записи
+// или IP-адреса).
+type LoginPolicy struct {
+	FreeAttempts int           // неудачных попыток без задержки
+	LockAfter    int           // после стольких попыток — блокировка на Lockout
+	BaseDelay    time.Duration // первая задержка; дальше удваивается
+	Lockout      time.Duration
+	Window       time.Duration // без новых ошибок счётчик забывается
+}
+
+// Политики по умолчанию. С одного IP пробуют разные учётные записи, поэтому
+// для адреса порог выше.
+var (
+	DefaultAccountLoginPolicy = LoginPolicy{FreeAttempts: 3, LockAfter: 10, BaseDelay: time.Second, Lockout: 15 * time.Minute, Window: time.Hour}
+	DefaultIPLoginPolicy      = LoginPolicy{FreeAttempts: 10, LockAfter: 50, BaseDelay: time.Second, Lockout: 15 * time.Minute, Window: time.Hour}
+)
+
+// delay возвращает, на сколько запретить попытки после failures ошибок
+// подряд, и наступила ли блокировка.
+func (p LoginPolicy) delay(failures int) (time.Duration, bool) {
+	if failures >= p.LockAfter {
+		return p.Lockout, true
+	}
+	if failures <= p.FreeAttempts {
+		return 0, false
+	}
+	d := p.BaseDelay
+	for i := p.FreeAttempts + 1; i < failures && d < p.Lockout; i++ {
+		d *= 2
+	}
+	if d > p.Lockout {
+		d = p.Lockout
+	}
+	return d, false
+}
+
+// ThrottledError — попытки входа временно запрещены (429).
+type ThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("too many failed login attempts; retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// LoginGuard считает неудачные входы по учётной записи и по IP-адресу в
+// MongoDB, чтобы ограничение действовало на всех экземплярах сервера. После
+// нескольких ошибок каждая следующая попытка откладывается вдвое дольше,
+// а после порога ключ блокируется на время.
+type LoginGuard struct {
+	repo    *repository.LoginThrottleRepo
+	users   *repository.UserRepo
+	audit   *AuditService
+	account LoginPolicy
+	ip      LoginPolicy
+}
+
+func NewLoginGuard(repo *repository.LoginThrottleRepo, users *repository.UserRepo, audit *AuditService, account, ip LoginPolicy) *LoginGuard {
+	return &LoginGuard{repo: repo, users: users, audit: audit, account: account, ip: ip}
+}
+
+// Check возвращает *ThrottledError, если попытку входа сейчас делать нельзя.
+// Вызывается до проверки пароля.
+func (g *LoginGuard) Check(ctx context.Context, email, ip string) error {
+	now := time.Now().UTC()
+	var wait time.Duration
+	for _, key := range []string{accountKey(email), ipKey(ip)} {
+		t, err := g.repo.Get(ctx, key, now)
+		if err != nil {
+			return err
+		}
+		if t != nil && t.BlockedUntil != nil && t.BlockedUntil.After(now) {
+			if d := t.BlockedUntil.Sub(now); d > wait {
+				wait = d
+			}
+		}
+	}
+	if wait > 0 {
+		return &ThrottledError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// Failure учитывает неудачную попытку входа.
+func (g *LoginGuard) Failure(ctx context.Context, email, ip string) error {
+	now := time.Now().UTC()
+	if err := g.fail(ctx, accountKey(email), g.account, now, func() {
+		g.recordLock(ctx, model.AuditLoginAccountLocked, email, map[string]interface{}{"email": email, "ip": ip})
+	}); err != nil {
+		return err
+	}
+	return g.fail(ctx, ipKey(ip), g.ip, now, func() {
+		g.recordLock(ctx, model.AuditLoginIPLocked, "", map[string]interface{}{"ip": ip})
+	})
+}
+
+// Success сбрасывает счётчик учётной записи. Счётчик IP не сбрасывается:
+// иначе вход в свою учётную запись позволял бы подбирать пароли к чужим.
+func (g *LoginGuard) Success(ctx context.Context, email string) error {
+	_, err := g.repo.Reset(ctx, accountKey(email))
+	return err
+}
+
+// Unlock снимает блокировку входа с учётной записи (admin).
+func (g *LoginGuard) Unlock(ctx context.Context, actor Actor, userID int) error {
+	u, err := g.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if u == nil {
+		return ErrNotFound
+	}
+	removed, err := g.repo.Reset(ctx, accountKey(u.Email))
+	if err != nil {
+		return err
+	}
+	g.audit.Record(ctx, actor, model.AuditLoginUnlocked, "user", userID, map[string]interface{}{"hadFailures": removed})
+	return nil
+}
+
+func (g *LoginGuard) fail(ctx context.Context, key string, p LoginPolicy, now time.Time, onLock func()) error {
+	t, err := g.repo.RecordFailure(ctx, key, now, now.Add(p.Window))
+	if err != nil {
+		return err
+	}
+	d, locked := p.delay(t.Failures)
+	if d == 0 {
+		return nil
+	}
+	if err := g.repo.Block(ctx, key, now.Add(d)); err != nil {
+		return err
+	}
+	// Событие пишем один раз — при достижении порога.
+	if locked && t.Failures == p.LockAfter {
+		onLock()
+	}
+	return nil
+}
+
+// recordLock пишет в аудит блокировку; для известной учётной записи —
+// с её id.
+func (g *LoginGuard) recordLock(ctx context.Context, action, email string, details map[string]interface{}) {
+	entity, entityID := "ip", 0
+	if email != "" {
+		entity = "user"
+		if u, err := g.users.GetByEmail(ctx, strings.TrimSpace(email)); err == nil && u != nil {
+			entityID = u.ID
+		}
+	}
+	g.audit.Record(ctx, Actor{}, action, entity, entityID, details)
+}
+
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginPolicyDelay(t *testing.T) {
+	p := LoginPolicy{FreeAttempts: 3, LockAfter: 10, BaseDelay: time.Second, Lockout: 15 * time.Minute}
+	tests := []struct {
+		failures   int
+		wantDelay  time.Duration
+		wantLocked bool
+	}{
+		{0, 0, false},
+		{3, 0, false},
+		{4, time.Second, false},
+		{5, 2 * time.Second, false},
+		{6, 4 * time.Second, false},
+		{9, 32 * time.Second, false},
+		{10, 15 * time.Minute, true},
+		{25, 15 * time.Minute, true},
+	}
+	for _, tt := range tests {
+		d, locked := p.delay(tt.failures)
+		if d != tt.wantDelay || locked != tt.wantLocked {
+			t.Errorf("delay(%d) = (%s, %v), want (%s, %v)", tt.failures, d, locked, tt.wantDelay, tt.wantLocked)
+		}
+	}
+}
+
+func TestLoginPolicyDelayCappedByLockout(t *testing.T) {
+	// Задержка удваивается, но не дольше самой блокировки.
+	p := LoginPolicy{FreeAttempts: 0, LockAfter: 100, BaseDelay: time.Minute, Lockout: 5 * time.Minute}
+	if d, locked := p.delay(50); d != 5*time.Minute || locked {
+		t.Errorf("delay(50) = (%s, %v), want (5m0s, false)", d, locked)
+	}
+}
@@ -3,26 +3,64 @@ package service
 import (
 	"cinema-system/model"
 	"cinema-system/repository"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Catalog page size limits.
+const (
+	defaultMoviePageSize = 20
+	maxMoviePageSize     = 100
 )
 
 // MovieService implements business logic for movies (Assignment 3 Service layer).
 type MovieService struct {
-	repo *repository.MovieRepo
+	repo     *repository.MovieRepo
+	sessions *repository.SessionRepo
+	genres   *GenreService
+	people   *PersonService
 }
 
 // NewMovieService creates a new movie service.
-func NewMovieService(repo *repository.MovieRepo) *MovieService {
-	return &MovieService{repo: repo}
+func NewMovieService(repo *repository.MovieRepo, sessions *repository.SessionRepo, genres *GenreService, people *PersonService) *MovieService {
+	return &MovieService{repo: repo, sessions: sessions, genres: genres, people: people}
 }
 
-// Create creates a new movie.
-func (s *MovieService) Create(m *model.Movie) (*model.Movie, error) {
+// Create creates a new movie. Its genres must exist in the genre catalog and
+// its credits must refer to existing people.
+func (s *MovieService) Create(ctx context.Context, m *model.Movie) (*model.Movie, error) {
+	if err := s.normalize(ctx, m, nil); err != nil {
+		return nil, err
+	}
 	return s.repo.Create(m)
 }
 
-// GetByID returns a movie by ID.
+// GetByID returns a movie by ID without credits.
 func (s *MovieService) GetByID(id int) (*model.Movie, error) {
-	return s.repo.GetByID(id)
+	m, err := s.repo.GetByID(id)
+	if m != nil {
+		m.Credits = nil
+	}
+	return m, err
+}
+
+// GetWithCredits returns a movie by ID with cast and crew, each credit
+// carrying the person it refers to.
+func (s *MovieService) GetWithCredits(ctx context.Context, id int) (*model.Movie, error) {
+	m, err := s.repo.GetByID(id)
+	if err != nil || m == nil {
+		return m, err
+	}
+	if err := s.people.EmbedCredits(ctx, m); err != nil {
+		return nil, err
+	}
+	if m.Credits == nil {
+		m.Credits = []model.Credit{}
+	}
+	return m, nil
 }
 
 // GetAll returns all movies.
@@ -30,12 +68,291 @@ func (s *MovieService) GetAll() ([]*model.Movie, error) {
 	return s.repo.GetAll()
 }
 
-// Update updates a movie.
-func (s *MovieService) Update(m *model.Movie) error {
+// MovieQuery holds the catalog parameters of GET /api/movies.
+type MovieQuery struct {
+	Search      string
+	GenreID     int
+	Statuses    []string // lifecycle statuses to show; now_showing when empty
+	AllStatuses bool     // ignore Statuses and show every movie, archived too
+	MinRating   *float64
+	MaxRating   *float64
+	MinDuration int
+	MaxDuration int
+	Sort        string // id, title, rating, duration
+	Order       string // asc, desc
+	Cursor      string // nextCursor of the previous page
+	Limit       int
+}
+
+// MoviePage is one page of the catalog. Total counts every movie matching the
+// filters; NextCursor is empty on the last page.
+type MoviePage struct {
+	Items      []*model.Movie `json:"items"`
+	Total      int64          `json:"total"`
+	Limit      int            `json:"limit"`
+	NextCursor string         `json:"nextCursor,omitempty"`
+}
+
+// movieCursor is the opaque cursor handed to clients. It remembers the sort
+// it was issued for, so it cannot be replayed against a different order.
+type movieCursor struct {
+	Sort  string      `json:"s"`
+	Desc  bool        `json:"d,omitempty"`
+	Value interface{} `json:"v,omitempty"`
+	ID    int         `json:"id"`
+}
+
+// List searches, filters and sorts the catalog with cursor pagination.
+func (s *MovieService) List(ctx context.Context, q MovieQuery) (*MoviePage, error) {
+	f := repository.MovieFilter{
+		Text:        strings.TrimSpace(q.Search),
+		GenreID:     q.GenreID,
+		MinRating:   q.MinRating,
+		MaxRating:   q.MaxRating,
+		MinDuration: q.MinDuration,
+		MaxDuration: q.MaxDuration,
+		Sort:        q.Sort,
+		Limit:       q.Limit,
+	}
+	switch {
+	case q.AllStatuses:
+	case len(q.Statuses) == 0:
+		f.Statuses = []string{model.MovieNowShowing}
+	default:
+		for _, st := range q.Statuses {
+			if !contains(model.MovieStatuses, st) {
+				return nil, invalidf("status must be all or one of %s", strings.Join(model.MovieStatuses, ", "))
+			}
+		}
+		f.Statuses = q.Statuses
+	}
+	if f.Sort == "" {
+		f.Sort = "id"
+	}
+	if !contains(repository.MovieSortFields, f.Sort) {
+		return nil, invalidf("sort must be one of %s", strings.Join(repository.MovieSortFields, ", "))
+	}
+	switch q.Order {
+	case "", "asc":
+	case "desc":
+		f.Desc = true
+	default:
+		return nil, invalidf("order must be asc or desc")
+	}
+	if f.MinRating != nil && f.MaxRating != nil && *f.MinRating > *f.MaxRating {
+		return nil, invalidf("minRating must not exceed maxRating")
+	}
+	if f.MinDuration < 0 || f.MaxDuration < 0 {
+		return nil, invalidf("duration must not be negative")
+	}
+	if f.MaxDuration > 0 && f.MinDuration > f.MaxDuration {
+		return nil, invalidf("minDuration must not exceed maxDuration")
+	}
+	if f.Limit <= 0 {
+		f.Limit = defaultMoviePageSize
+	}
+	if f.Limit > maxMoviePageSize {
+		f.Limit = maxMoviePageSize
+	}
+	if q.Cursor != "" {
+		c, err := decodeMovieCursor(q.Cursor)
+		if err != nil || c.Sort != f.Sort || c.Desc != f.Desc {
+			return nil, invalidf("invalid cursor")
+		}
+		f.After = &repository.MovieCursor{Value: c.Value, ID: c.ID}
+	}
+
+	limit := f.Limit
+	f.Limit++ // one extra movie tells whether there is a next page
+	items, total, err := s.repo.Find(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range items {
+		m.Credits = nil
+	}
+	page := &MoviePage{Items: items, Total: total, Limit: limit}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		last := page.Items[limit-1]
+		page.NextCursor = encodeMovieCursor(movieCursor{Sort: f.Sort, Desc: f.Desc, Value: movieSortValue(last, f.Sort), ID: last.ID})
+	}
+	if page.Items == nil {
+		page.Items = []*model.Movie{}
+	}
+	return page, nil
+}
+
+func movieSortValue(m *model.Movie, field string) interface{} {
+	switch field {
+	case "title":
+		return m.Title
+	case "rating":
+		return m.Rating
+	case "duration":
+		return m.Duration
+	}
+	return nil
+}
+
+func encodeMovieCursor(c movieCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeMovieCursor(s string) (movieCursor, error) {
+	var c movieCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(b, &c)
+	return c, err
+}
+
+// Update updates a movie, replacing its genres. Credits are replaced only when
+// the request has them: a missing "credits" field keeps the stored ones, an
+// empty list removes them.
+func (s *MovieService) Update(ctx context.Context, m *model.Movie) error {
+	old, err := s.repo.GetByID(m.ID)
+	if err != nil {
+		return err
+	}
+	if old == nil {
+		return ErrNotFound
+	}
+	keepCredits := m.Credits == nil
+	if err := s.normalize(ctx, m, old); err != nil {
+		return err
+	}
+	if keepCredits {
+		m.Credits = old.Credits
+	}
+	if m.Status == model.MovieArchived && old.Status != model.MovieArchived {
+		if err := s.checkNoUpcomingSessions(ctx, m.ID, time.Now()); err != nil {
+			return err
+		}
+	}
 	return s.repo.Update(m)
 }
 
-// Delete deletes a movie by ID.
-func (s *MovieService) Delete(id int) error {
-	return s.repo.Delete(id)
+// normalize checks references and fields shared by Create and Update. old is
+// the stored movie on update and nil on create.
+func (s *MovieService) normalize(ctx context.Context, m *model.Movie, old *model.Movie) error {
+	if err := normalizeLifecycle(m, old, time.Now()); err != nil {
+		return err
+	}
+	m.Country = strings.TrimSpace(m.Country)
+	m.AgeRating = strings.TrimSpace(m.AgeRating)
+	if m.AgeRating != "" && !contains(model.AgeRatings, m.AgeRating) {
+		return invalidf("ageRating must be one of %s", strings.Join(model.AgeRatings, ", "))
+	}
+	if m.Year != 0 && (m.Year < 1888 || m.Year > time.Now().Year()+5) {
+		return invalidf("year %d is out of range", m.Year)
+	}
+	genres, err := s.genres.Resolve(ctx, m.Genres)
+	if err != nil {
+		return err
+	}
+	m.Genres = genres
+	credits, err := s.people.ResolveCredits(ctx, m.Credits)
+	if err != nil {
+		return err
+	}
+	m.Credits = credits
+	return nil
+}
+
+// normalizeLifecycle defaults and checks the release dates and status. An
+// update without a status keeps the stored one; a new movie without a status
+// is announced until its release date and showing after it.
+func normalizeLifecycle(m *model.Movie, old *model.Movie, now time.Time) error {
+	m.Status = strings.TrimSpace(m.Status)
+	if m.Status == "" && old != nil {
+		m.Status = old.Status
+	}
+	if m.Status == "" {
+		m.Status = model.MovieNowShowing
+		if m.ReleaseDate != nil && m.ReleaseDate.After(now) {
+			m.Status = model.MovieAnnounced
+		}
+	}
+	if !contains(model.MovieStatuses, m.Status) {
+		return invalidf("status must be one of %s", strings.Join(model.MovieStatuses, ", "))
+	}
+	if m.ReleaseDate != nil && m.EndOfRun != nil && !m.EndOfRun.After(*m.ReleaseDate) {
+		return invalidf("endOfRun must be after releaseDate")
+	}
+	switch {
+	case m.Status != model.MovieArchived:
+		m.ArchivedAt = nil
+	case old != nil && old.ArchivedAt != nil:
+		m.ArchivedAt = old.ArchivedAt
+	default:
+		m.ArchivedAt = &now
+	}
+	return nil
+}
+
+// Delete archives a movie: it leaves the catalog but stays readable by ID for
+// past sessions and bookings. Movies with upcoming sessions cannot be
+// archived.
+func (s *MovieService) Delete(ctx context.Context, id int) error {
+	m, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		return ErrNotFound
+	}
+	if m.Status == model.MovieArchived {
+		return nil
+	}
+	now := time.Now()
+	if err := s.checkNoUpcomingSessions(ctx, id, now); err != nil {
+		return err
+	}
+	_, err = s.repo.Archive(ctx, id, now)
+	return err
+}
+
+func (s *MovieService) checkNoUpcomingSessions(ctx context.Context, id int, now time.Time) error {
+	upcoming, err := s.sessions.Find(ctx, repository.SessionFilter{MovieID: id, From: now})
+	if err != nil {
+		return err
+	}
+	if len(upcoming) > 0 {
+		return &ConflictError{Msg: "movie has upcoming sessions"}
+	}
+	return nil
+}
+
+// AdvanceStatuses moves movies along their lifecycle by release and
+// end-of-run dates. A movie whose run has ended stays on screen while it
+// still has upcoming sessions, so tickets for them can be sold. It returns
+// the number of movies changed.
+func (s *MovieService) AdvanceStatuses(ctx context.Context) (int, error) {
+	now := time.Now()
+	n, err := s.repo.Release(ctx, now)
+	if err != nil {
+		return n, err
+	}
+	ended, err := s.repo.RunEnded(ctx, now)
+	if err != nil {
+		return n, err
+	}
+	for _, m := range ended {
+		upcoming, err := s.sessions.Find(ctx, repository.SessionFilter{MovieID: m.ID, From: now})
+		if err != nil {
+			return n, err
+		}
+		if len(upcoming) > 0 {
+			continue
+		}
+		if _, err := s.repo.Archive(ctx, m.ID, now); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
 }
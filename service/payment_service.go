@@ -0,0 +1,270 @@
+package service
+
+import (
+	"cinema-system/model"
+	"cinema-system/payment"
+	"cinema-system/realtime"
+	"cinema-system/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+)
+
+// ErrBadSignature — подпись вебхука не сошлась.
+var ErrBadSignature = errors.New("invalid webhook signature")
+
+// PaymentService — оплата бронирований через платёжного провайдера.
+type PaymentService struct {
+	repo     *repository.PaymentRepo
+	refunds  *repository.RefundRepo
+	bookings *repository.BookingRepo
+	provider payment.Provider
+	events   realtime.Publisher
+	secret   []byte
+	timeout  time.Duration
+}
+
+// NewPaymentService создаёт сервис оплаты. secret — общий с провайдером ключ
+// подписи вебхуков; timeout — сколько бронирование может ждать оплаты, прежде
+// чем места будут освобождены.
+func NewPaymentService(repo *repository.PaymentRepo, refunds *repository.RefundRepo, bookings *repository.BookingRepo, provider payment.Provider, events realtime.Publisher, secret []byte, timeout time.Duration) *PaymentService {
+	return &PaymentService{repo: repo, refunds: refunds, bookings: bookings, provider: provider, events: events, secret: secret, timeout: timeout}
+}
+
+// Pay создаёт намерение оплаты бронирования и передаёт платёж провайдеру.
+// Результат придёт на вебхук; статус можно узнать через Get.
+func (s *PaymentService) Pay(ctx context.Context, actor Actor, bookingID int, card string) (*model.PaymentIntent, error) {
+	b, err := s.bookings.GetByID(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil || (b.UserID != actor.UserID && !actor.Can(model.PermBookingsManage)) {
+		return nil, ErrNotFound
+	}
+	if b.Status != model.BookingPending {
+		return nil, &ConflictError{Msg: "booking is " + b.Status + ", not awaiting payment"}
+	}
+	pending, err := s.repo.PendingForBooking(ctx, b.ID)
+	if err != nil {
+		return nil, err
+	}
+	if pending != nil {
+		return nil, &ConflictError{Msg: "payment is already in progress", Conflict: pending}
+	}
+
+	now := time.Now().UTC()
+	intent, err := s.repo.Create(ctx, &model.PaymentIntent{
+		BookingID: b.ID,
+		UserID:    b.UserID,
+		Amount:    b.Total,
+		Currency:  "KZT",
+		Provider:  s.provider.Name(),
+		Status:    model.PaymentPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if errors.Is(err, repository.ErrPaymentInProgress) {
+		return nil, &ConflictError{Msg: "payment is already in progress"}
+	}
+	if err != nil {
+		return nil, err
+	}
+	ref, err := s.provider.Charge(ctx, payment.ChargeRequest{
+		IntentID: intent.ID,
+		Amount:   intent.Amount,
+		Currency: intent.Currency,
+		Card:     card,
+	})
+	if err != nil {
+		_, _ = s.repo.Finish(ctx, intent.ID, model.PaymentFailed, "provider error")
+		return nil, err
+	}
+	if err := s.repo.SetProviderRef(ctx, intent.ID, ref); err != nil {
+		return nil, err
+	}
+	intent.ProviderRef = ref
+	return intent, nil
+}
+
+// Get возвращает намерение оплаты владельцу бронирования или сотруднику.
+func (s *PaymentService) Get(ctx context.Context, actor Actor, id int) (*model.PaymentIntent, error) {
+	p, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil || (p.UserID != actor.UserID && !actor.Can(model.PermBookingsManage)) {
+		return nil, ErrNotFound
+	}
+	return p, nil
+}
+
+// HandleWebhook проверяет подпись и применяет результат платежа: бронирование
+// становится paid или failed. Повторные вебхуки ничего не меняют.
+func (s *PaymentService) HandleWebhook(ctx context.Context, body []byte, signature string) error {
+	if !payment.Verify(s.secret, body, signature) {
+		return ErrBadSignature
+	}
+	var ev payment.WebhookEvent
+	if err := json.Unmarshal(body, &ev); err != nil {
+		return invalidf("invalid webhook payload")
+	}
+	if ev.Status != payment.StatusSucceeded && ev.Status != payment.StatusFailed {
+		return invalidf("unknown payment status %q", ev.Status)
+	}
+
+	// Вебхук может прийти раньше, чем Pay сохранит provider_ref; подождём немного.
+	var intent *model.PaymentIntent
+	for attempt := 0; attempt < 5; attempt++ {
+		var err error
+		if intent, err = s.repo.GetByProviderRef(ctx, s.provider.Name(), ev.ProviderRef); err != nil {
+			return err
+		}
+		if intent != nil {
+			break
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	if intent == nil {
+		return ErrNotFound
+	}
+
+	status := model.PaymentSucceeded
+	if ev.Status == payment.StatusFailed {
+		status = model.PaymentFailed
+	}
+	changed, err := s.repo.Finish(ctx, intent.ID, status, ev.Reason)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		if status != model.PaymentSucceeded {
+			return nil
+		}
+		// Платёж закрыли по таймауту, а провайдер всё-таки списал деньги —
+		// бронирования уже нет, возвращаем всю сумму. SucceedFailed не даёт
+		// повторному вебхуку вернуть деньги второй раз.
+		late, err := s.repo.SucceedFailed(ctx, intent.ID)
+		if err != nil || !late {
+			return err
+		}
+		log.Printf("[payment] intent %d succeeded after it was failed; refunding", intent.ID)
+		_, err = s.refund(ctx, intent, intent.Amount, "payment arrived after the booking expired")
+		return err
+	}
+
+	if status == model.PaymentSucceeded {
+		paid, err := s.bookings.MarkPaid(ctx, intent.BookingID, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+		if !paid {
+			// Бронирование успело истечь или было отменено до прихода оплаты —
+			// возвращаем деньги полностью.
+			log.Printf("[payment] intent %d succeeded but booking %d is no longer pending; refunding", intent.ID, intent.BookingID)
+			_, err = s.refund(ctx, intent, intent.Amount, "booking was no longer awaiting payment")
+			return err
+		}
+		return nil
+	}
+	return s.failBooking(ctx, intent.BookingID)
+}
+
+// InProgress возвращает незавершённый платёж по бронированию или nil.
+func (s *PaymentService) InProgress(ctx context.Context, bookingID int) (*model.PaymentIntent, error) {
+	return s.repo.PendingForBooking(ctx, bookingID)
+}
+
+// Refund возвращает amount по успешному платежу бронирования и сохраняет запись
+// о возврате. Если бронирование не было оплачено, возвращает nil. Отказ
+// провайдера не считается ошибкой: возврат сохраняется со статусом failed.
+func (s *PaymentService) Refund(ctx context.Context, bookingID, amount int, reason string) (*model.Refund, error) {
+	intent, err := s.repo.SucceededForBooking(ctx, bookingID)
+	if err != nil || intent == nil {
+		return nil, err
+	}
+	return s.refund(ctx, intent, amount, reason)
+}
+
+func (s *PaymentService) refund(ctx context.Context, intent *model.PaymentIntent, amount int, reason string) (*model.Refund, error) {
+	if amount > intent.Amount {
+		amount = intent.Amount
+	}
+	r := &model.Refund{
+		BookingID: intent.BookingID,
+		PaymentID: intent.ID,
+		Amount:    amount,
+		Currency:  intent.Currency,
+		Provider:  s.provider.Name(),
+		Status:    model.RefundSucceeded,
+		Reason:    reason,
+		CreatedAt: time.Now().UTC(),
+	}
+	ref, err := s.provider.Refund(ctx, payment.RefundRequest{
+		PaymentRef: intent.ProviderRef,
+		Amount:     amount,
+		Currency:   intent.Currency,
+	})
+	if err != nil {
+		log.Printf("[payment] refund of intent %d failed: %v", intent.ID, err)
+		r.Status = model.RefundFailed
+		r.FailureReason = err.Error()
+	}
+	r.ProviderRef = ref
+	return s.refunds.Create(ctx, r)
+}
+
+// ExpirePending отменяет бронирования, не оплаченные за отведённое время
+// (например, если провайдер так и не прислал вебхук). Время считается от
+// начала последней попытки оплаты, а без неё — от создания бронирования.
+// Вызывается фоновой задачей.
+func (s *PaymentService) ExpirePending(ctx context.Context) (int, error) {
+	cutoff := time.Now().UTC().Add(-s.timeout)
+	stale, err := s.bookings.PendingCreatedBefore(ctx, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	expired := 0
+	for _, b := range stale {
+		pending, err := s.repo.PendingForBooking(ctx, b.ID)
+		if err != nil {
+			return expired, err
+		}
+		if pending != nil && pending.CreatedAt.After(cutoff) {
+			continue
+		}
+		if err := s.repo.FailPendingForBooking(ctx, b.ID, "payment timeout"); err != nil {
+			return expired, err
+		}
+		if err := s.failBooking(ctx, b.ID); err != nil {
+			return expired, err
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+func (s *PaymentService) failBooking(ctx context.Context, bookingID int) error {
+	b, err := s.bookings.MarkFailed(ctx, bookingID)
+	if err != nil || b == nil {
+		return err
+	}
+	s.events.Publish(realtime.SeatEvent{SessionID: b.SessionID, State: model.SeatStateFree, Seats: ticketSeats(b.Tickets)})
+	return nil
+}
+
+// ScriptFake задаёт исходы следующих платежей тестового провайдера.
+func (s *PaymentService) ScriptFake(outcomes []payment.Outcome) error {
+	fake, ok := s.provider.(*payment.FakeProvider)
+	if !ok {
+		return invalidf("payment provider %q cannot be scripted", s.provider.Name())
+	}
+	for _, o := range outcomes {
+		if !payment.ValidOutcome(o) {
+			return invalidf("unknown outcome %q", o)
+		}
+	}
+	fake.Script(outcomes...)
+	return nil
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"cinema-system/model"
+	"cinema-system/repository"
+	"context"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Размер страницы списка людей.
+const (
+	defaultPersonPageSize = 20
+	maxPersonPageSize     = 100
+)
+
+// PersonService — актёры и съёмочная группа, титры фильмов.
+type PersonService struct {
+	repo   *repository.PersonRepo
+	movies *repository.MovieRepo
+}
+
+func NewPersonService(repo *repository.PersonRepo, movies *repository.MovieRepo) *PersonService {
+	return &PersonService{repo: repo, movies: movies}
+}
+
+// PersonQuery — параметры списка людей.
+type PersonQuery struct {
+	Search   string
+	Page     int // с 1
+	PageSize int
+}
+
+// PersonPage — страница списка людей.
+type PersonPage struct {
+	Items    []*model.Person `json:"items"`
+	Total    int64           `json:"total"`
+	Page     int             `json:"page"`
+	PageSize int             `json:"pageSize"`
+}
+
+// List возвращает людей по алфавиту с поиском по имени.
+func (s *PersonService) List(ctx context.Context, q PersonQuery) (*PersonPage, error) {
+	if q.Page <= 0 {
+		q.Page = 1
+	}
+	if q.PageSize <= 0 {
+		q.PageSize = defaultPersonPageSize
+	}
+	if q.PageSize > maxPersonPageSize {
+		q.PageSize = maxPersonPageSize
+	}
+	items, total, err := s.repo.Find(ctx, repository.PersonFilter{
+		Query: strings.TrimSpace(q.Search),
+		Skip:  (q.Page - 1) * q.PageSize,
+		Limit: q.PageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []*model.Person{}
+	}
+	return &PersonPage{Items: items, Total: total, Page: q.Page, PageSize: q.PageSize}, nil
+}
+
+// Get возвращает человека по id.
+func (s *PersonService) Get(ctx context.Context, id int) (*model.Person, error) {
+	p, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, ErrNotFound
+	}
+	return p, nil
+}
+
+// Create добавляет человека.
+func (s *PersonService) Create(ctx context.Context, p *model.Person) (*model.Person, error) {
+	if err := normalizePerson(p); err != nil {
+		return nil, err
+	}
+	return s.repo.Create(ctx, p)
+}
+
+// Update заменяет данные человека.
+func (s *PersonService) Update(ctx context.Context, p *model.Person) error {
+	if err := normalizePerson(p); err != nil {
+		return err
+	}
+	found, err := s.repo.Update(ctx, p)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete удаляет человека, если его нет в титрах ни одного фильма.
+// Несуществующий человек — ErrNotFound.
+func (s *PersonService) Delete(ctx context.Context, id int) error {
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+	n, err := s.movies.CountByPerson(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return &ConflictError{Msg: "person is credited in movies"}
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+// FilmographyItem — фильм и роли в нём одного человека.
+type FilmographyItem struct {
+	Movie   *model.Movie   `json:"movie"`
+	Credits []model.Credit `json:"credits"`
+}
+
+// Movies возвращает фильмы с участием человека, новые первыми.
+func (s *PersonService) Movies(ctx context.Context, id int) ([]*FilmographyItem, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	movies, err := s.movies.ByPerson(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*FilmographyItem, 0, len(movies))
+	for _, m := range movies {
+		item := &FilmographyItem{Movie: m}
+		for _, c := range m.Credits {
+			if c.PersonID == id {
+				item.Credits = append(item.Credits, c)
+			}
+		}
+		m.Credits = nil
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+// ResolveCredits проверяет титры фильма: люди существуют, роль известна,
+// персонаж указан только у актёров, одна и та же роль не повторяется.
+// Титры сортируются по Order.
+func (s *PersonService) ResolveCredits(ctx context.Context, credits []model.Credit) ([]model.Credit, error) {
+	if len(credits) == 0 {
+		return nil, nil
+	}
+	ids := make([]int, 0, len(credits))
+	type key struct {
+		person    int
+		role      string
+		character string
+	}
+	seen := make(map[key]bool, len(credits))
+	for i := range credits {
+		c := &credits[i]
+		c.Role = strings.ToLower(strings.TrimSpace(c.Role))
+		c.Character = strings.TrimSpace(c.Character)
+		c.Person = nil
+		if !contains(model.CreditRoles, c.Role) {
+			return nil, invalidf("credit role must be one of %s", strings.Join(model.CreditRoles, ", "))
+		}
+		if c.Character != "" && c.Role != model.CreditActor {
+			return nil, invalidf("only actors have a character")
+		}
+		k := key{c.PersonID, c.Role, c.Character}
+		if seen[k] {
+			return nil, invalidf("person %d is credited twice as %s", c.PersonID, c.Role)
+		}
+		seen[k] = true
+		ids = append(ids, c.PersonID)
+	}
+	people, err := s.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range credits {
+		if people[c.PersonID] == nil {
+			return nil, invalidf("person %d does not exist", c.PersonID)
+		}
+	}
+	sort.SliceStable(credits, func(i, j int) bool { return credits[i].Order < credits[j].Order })
+	return credits, nil
+}
+
+// EmbedCredits подставляет в титры фильма данные людей.
+func (s *PersonService) EmbedCredits(ctx context.Context, m *model.Movie) error {
+	ids := make([]int, 0, len(m.Credits))
+	for _, c := range m.Credits {
+		ids = append(ids, c.PersonID)
+	}
+	people, err := s.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range m.Credits {
+		m.Credits[i].Person = people[m.Credits[i].PersonID]
+	}
+	return nil
+}
+
+func normalizePerson(p *model.Person) error {
+	p.Name = strings.TrimSpace(p.Name)
+	p.OriginalName = strings.TrimSpace(p.OriginalName)
+	p.Country = strings.TrimSpace(p.Country)
+	if p.Name == "" {
+		return invalidf("person name is required")
+	}
+	if p.BirthYear != 0 && (p.BirthYear < 1850 || p.BirthYear > time.Now().Year()) {
+		return invalidf("birth year %d is out of range", p.BirthYear)
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"cinema-system/model"
+	"cinema-system/repository"
+	"context"
+	"strings"
+	"time"
+)
+
+// TicketTypes — типы билетов, которые продаёт кинотеатр.
+var TicketTypes = []string{model.TicketAdult, model.TicketStudent, model.TicketChild}
+
+// PricingService считает цены билетов на сервере по правилам ценообразования.
+type PricingService struct {
+	repo     *repository.PricingRepo
+	sessions *repository.SessionRepo
+	halls    *repository.HallRepo
+	loc      *time.Location
+}
+
+// NewPricingService создаёт сервис цен; loc — часовой пояс кинотеатра, в нём
+// проверяются условия по дню недели и времени сеанса.
+func NewPricingService(repo *repository.PricingRepo, sessions *repository.SessionRepo, halls *repository.HallRepo, loc *time.Location) *PricingService {
+	return &PricingService{repo: repo, sessions: sessions, halls: halls, loc: loc}
+}
+
+// Pricer считает цены для одного сеанса по заранее загруженным правилам.
+type Pricer struct {
+	rules   []*model.PricingRule
+	session *model.Session
+	hall    *model.Hall
+	weekday int
+	minute  int // минута суток начала сеанса
+}
+
+// For загружает действующие правила и возвращает калькулятор для сеанса.
+func (s *PricingService) For(ctx context.Context, sess *model.Session, hall *model.Hall) (*Pricer, error) {
+	rules, err := s.repo.GetAll(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	start := sess.StartTime.In(s.loc)
+	return &Pricer{
+		rules:   rules,
+		session: sess,
+		hall:    hall,
+		weekday: int(start.Weekday()),
+		minute:  start.Hour()*60 + start.Minute(),
+	}, nil
+}
+
+// Price возвращает цену билета данного типа на место данной категории.
+func (p *Pricer) Price(ticketType, seatCategory string) (int, error) {
+	if !validTicketType(ticketType) {
+		return 0, invalidf("unknown ticket type %q", ticketType)
+	}
+	price := p.session.BasePrice
+	for _, r := range p.rules {
+		if !p.matches(r, ticketType, seatCategory) {
+			continue
+		}
+		price = price*r.Percent/100 + r.Amount
+	}
+	if price < 0 {
+		price = 0
+	}
+	// Округляем до 10 ₸, как на кассе.
+	return (price + 5) / 10 * 10, nil
+}
+
+func (p *Pricer) matches(r *model.PricingRule, ticketType, seatCategory string) bool {
+	if r.TicketType != "" && r.TicketType != ticketType {
+		return false
+	}
+	if r.SeatCategory != "" && r.SeatCategory != seatCategory {
+		return false
+	}
+	if r.HallFormat != "" && r.HallFormat != p.hall.Format {
+		return false
+	}
+	if len(r.Weekdays) > 0 {
+		found := false
+		for _, d := range r.Weekdays {
+			if d == p.weekday {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return inTimeWindow(p.minute, r.FromTime, r.ToTime)
+}
+
+// inTimeWindow проверяет, что минута суток попадает в [from, to). Если from
+// позже to, окно переходит через полночь: 22:00–02:00 — это и 23:30, и 01:00.
+// День недели при этом берётся по началу сеанса, то есть сеанс в 01:00
+// пятницы относится к пятнице.
+func inTimeWindow(minute int, from, to string) bool {
+	if from != "" && to != "" && clockMinutes(from) > clockMinutes(to) {
+		return minute >= clockMinutes(from) || minute < clockMinutes(to)
+	}
+	if from != "" && minute < clockMinutes(from) {
+		return false
+	}
+	if to != "" && minute >= clockMinutes(to) {
+		return false
+	}
+	return true
+}
+
+// PriceList возвращает цены всех типов билетов по категориям мест зала сеанса.
+func (s *PricingService) PriceList(ctx context.Context, sessionID int) (*model.PriceList, error) {
+	sess, err := s.sessions.GetByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if sess == nil {
+		return nil, ErrNotFound
+	}
+	hall, err := s.halls.GetByID(ctx, sess.HallID)
+	if err != nil {
+		return nil, err
+	}
+	if hall == nil {
+		return nil, ErrNotFound
+	}
+	pricer, err := s.For(ctx, sess, hall)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &model.PriceList{SessionID: sess.ID, Prices: map[string]map[string]int{}}
+	for _, row := range hall.Rows {
+		for _, seat := range row.Seats {
+			if seat.Kind != model.SeatKindSeat || list.Prices[seat.Category] != nil {
+				continue
+			}
+			byType := make(map[string]int, len(TicketTypes))
+			for _, t := range TicketTypes {
+				if byType[t], err = pricer.Price(t, seat.Category); err != nil {
+					return nil, err
+				}
+			}
+			list.Prices[seat.Category] = byType
+		}
+	}
+	return list, nil
+}
+
+// Create добавляет правило. Правило без active создаётся включённым.
+func (s *PricingService) Create(ctx context.Context, r *model.PricingRule) (*model.PricingRule, error) {
+	if err := normalizeRule(r); err != nil {
+		return nil, err
+	}
+	if r.Active == nil {
+		active := true
+		r.Active = &active
+	}
+	return s.repo.Create(ctx, r)
+}
+
+// GetAll возвращает все правила, включая выключенные.
+func (s *PricingService) GetAll(ctx context.Context) ([]*model.PricingRule, error) {
+	return s.repo.GetAll(ctx, false)
+}
+
+// GetByID возвращает правило по id.
+func (s *PricingService) GetByID(ctx context.Context, id int) (*model.PricingRule, error) {
+	r, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, ErrNotFound
+	}
+	return r, nil
+}
+
+// Update заменяет правило. Без active правило остаётся включённым или
+// выключенным, как было.
+func (s *PricingService) Update(ctx context.Context, r *model.PricingRule) error {
+	if err := normalizeRule(r); err != nil {
+		return err
+	}
+	if r.Active == nil {
+		old, err := s.repo.GetByID(ctx, r.ID)
+		if err != nil {
+			return err
+		}
+		if old == nil {
+			return ErrNotFound
+		}
+		r.Active = old.Active
+	}
+	found, err := s.repo.Update(ctx, r)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete удаляет правило.
+func (s *PricingService) Delete(ctx context.Context, id int) error {
+	return s.repo.Delete(ctx, id)
+}
+
+func normalizeRule(r *model.PricingRule) error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return invalidf("rule name is required")
+	}
+	if r.TicketType != "" && !validTicketType(r.TicketType) {
+		return invalidf("unknown ticket type %q", r.TicketType)
+	}
+	if r.SeatCategory != "" && !validSeatCategory(r.SeatCategory) {
+		return invalidf("unknown seat category %q", r.SeatCategory)
+	}
+	r.HallFormat = strings.ToLower(strings.TrimSpace(r.HallFormat))
+	for _, d := range r.Weekdays {
+		if d < 0 || d > 6 {
+			return invalidf("weekday must be between 0 (Sunday) and 6 (Saturday)")
+		}
+	}
+	for _, t := range []string{r.FromTime, r.ToTime} {
+		if t == "" {
+			continue
+		}
+		if _, err := time.Parse("15:04", t); err != nil {
+			return invalidf("time %q must be in HH:MM format", t)
+		}
+	}
+	if r.FromTime != "" && r.FromTime == r.ToTime {
+		return invalidf("fromTime and toTime must differ")
+	}
+	if r.Percent < 0 {
+		return invalidf("percent must not be negative")
+	}
+	if r.Percent == 0 && r.Amount == 0 {
+		return invalidf("rule must change the price: set percent or amount")
+	}
+	if r.Percent == 0 {
+		r.Percent = 100
+	}
+	return nil
+}
+
+func validTicketType(t string) bool {
+	for _, known := range TicketTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// clockMinutes переводит "HH:MM" в минуты от начала суток (формат уже проверен).
+func clockMinutes(hhmm string) int {
+	t, err := time.Parse("15:04", hhmm)
+	if err != nil {
+		return 0
+	}
+	return t.Hour()*60 + t.Minute()
+}
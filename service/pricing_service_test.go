@@ -0,0 +1,81 @@
+package service
+
+import (
+	"cinema-system/model"
+	"testing"
+)
+
+func TestPricerPrice(t *testing.T) {
+	student := &model.PricingRule{TicketType: model.TicketStudent, Percent: 80}
+	vip := &model.PricingRule{SeatCategory: model.SeatCategoryVIP, Percent: 100, Amount: 500}
+	half := &model.PricingRule{Percent: 50}
+	plus1000 := &model.PricingRule{Percent: 100, Amount: 1000}
+	evening := &model.PricingRule{FromTime: "18:00", Percent: 100, Amount: 300}
+	morning := &model.PricingRule{ToTime: "18:00", Percent: 50}
+	saturday := &model.PricingRule{Weekdays: []int{6}, Percent: 200}
+	imax := &model.PricingRule{HallFormat: "imax", Percent: 150}
+	night := &model.PricingRule{FromTime: "22:00", ToTime: "02:00", Percent: 70}
+
+	tests := []struct {
+		name     string
+		base     int
+		rules    []*model.PricingRule
+		ticket   string
+		category string
+		minute   int
+		want     int
+	}{
+		{"base price without rules", 2000, nil, model.TicketAdult, model.SeatCategoryStandard, 600, 2000},
+		{"rounds down below 5", 1234, nil, model.TicketAdult, model.SeatCategoryStandard, 600, 1230},
+		{"rounds up from 5", 1235, nil, model.TicketAdult, model.SeatCategoryStandard, 600, 1240},
+		{"rounds after rules", 1999, []*model.PricingRule{student}, model.TicketStudent, model.SeatCategoryStandard, 600, 1600},
+		{"ticket type rule skips other types", 2000, []*model.PricingRule{student}, model.TicketAdult, model.SeatCategoryStandard, 600, 2000},
+		{"rules add up", 2000, []*model.PricingRule{student, vip}, model.TicketStudent, model.SeatCategoryVIP, 600, 2100},
+		// Правила приходят из репозитория уже по приоритету; порядок важен.
+		{"percent before amount", 2000, []*model.PricingRule{half, plus1000}, model.TicketAdult, model.SeatCategoryStandard, 600, 2000},
+		{"amount before percent", 2000, []*model.PricingRule{plus1000, half}, model.TicketAdult, model.SeatCategoryStandard, 600, 1500},
+		{"from time is inclusive", 2000, []*model.PricingRule{evening}, model.TicketAdult, model.SeatCategoryStandard, 18 * 60, 2300},
+		{"to time is exclusive", 2000, []*model.PricingRule{morning}, model.TicketAdult, model.SeatCategoryStandard, 18 * 60, 2000},
+		{"before to time", 2000, []*model.PricingRule{morning}, model.TicketAdult, model.SeatCategoryStandard, 18*60 - 1, 1000},
+		// Ночное окно 22:00–02:00 переходит через полночь.
+		{"night window before midnight", 2000, []*model.PricingRule{night}, model.TicketAdult, model.SeatCategoryStandard, 23*60 + 30, 1400},
+		{"night window after midnight", 2000, []*model.PricingRule{night}, model.TicketAdult, model.SeatCategoryStandard, 60, 1400},
+		{"night window end is exclusive", 2000, []*model.PricingRule{night}, model.TicketAdult, model.SeatCategoryStandard, 2 * 60, 2000},
+		{"outside night window", 2000, []*model.PricingRule{night}, model.TicketAdult, model.SeatCategoryStandard, 12 * 60, 2000},
+		{"other weekday", 2000, []*model.PricingRule{saturday}, model.TicketAdult, model.SeatCategoryStandard, 600, 2000},
+		{"other hall format", 2000, []*model.PricingRule{imax}, model.TicketAdult, model.SeatCategoryStandard, 600, 2000},
+		{"never below zero", 2000, []*model.PricingRule{{Percent: 100, Amount: -5000}}, model.TicketAdult, model.SeatCategoryStandard, 600, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pricer{
+				rules:   tt.rules,
+				session: &model.Session{BasePrice: tt.base},
+				hall:    &model.Hall{Format: model.HallFormatStandard},
+				weekday: 1,
+				minute:  tt.minute,
+			}
+			got, err := p.Price(tt.ticket, tt.category)
+			if err != nil {
+				t.Fatalf("Price: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Price = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPricerPriceUnknownTicketType(t *testing.T) {
+	p := &Pricer{session: &model.Session{BasePrice: 2000}, hall: &model.Hall{}}
+	if _, err := p.Price("pensioner", model.SeatCategoryStandard); err == nil {
+		t.Fatal("Price accepted an unknown ticket type")
+	}
+}
+
+func TestNormalizeRuleRejectsEmptyWindow(t *testing.T) {
+	r := &model.PricingRule{Name: "Ночь", FromTime: "22:00", ToTime: "22:00", Percent: 70}
+	if err := normalizeRule(r); err == nil {
+		t.Fatal("normalizeRule accepted a window with equal bounds")
+	}
+}
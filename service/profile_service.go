@@ -0,0 +1,282 @@
+package service
+
+import (
+	"cinema-system/eticket"
+	"cinema-system/model"
+	"cinema-system/repository"
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ProfileService — личный кабинет: профиль, смена email и пароля, история
+// билетов и удаление учётной записи самим пользователем.
+type ProfileService struct {
+	users    *repository.UserRepo
+	tokens   *repository.UserTokenRepo
+	bookings *repository.BookingRepo
+	sessions *repository.SessionRepo
+	movies   *repository.MovieRepo
+	halls    *repository.HallRepo
+	accounts *AccountService
+	logins   *TokenService
+	audit    *AuditService
+	signer   *eticket.Signer
+}
+
+func NewProfileService(users *repository.UserRepo, tokens *repository.UserTokenRepo, bookings *repository.BookingRepo, sessions *repository.SessionRepo, movies *repository.MovieRepo, halls *repository.HallRepo, accounts *AccountService, logins *TokenService, audit *AuditService, signer *eticket.Signer) *ProfileService {
+	return &ProfileService{users: users, tokens: tokens, bookings: bookings, sessions: sessions, movies: movies, halls: halls, accounts: accounts, logins: logins, audit: audit, signer: signer}
+}
+
+// ProfileUpdate — изменения профиля; nil-поля не меняются. Для смены email и
+// пароля нужен текущий пароль.
+type ProfileUpdate struct {
+	Name            *string `json:"name"`
+	Language        *string `json:"language"`
+	Email           *string `json:"email"`
+	Password        *string `json:"password"`
+	CurrentPassword string  `json:"currentPassword"`
+}
+
+// Get возвращает профиль текущего пользователя.
+func (s *ProfileService) Get(ctx context.Context, actor Actor) (*model.User, error) {
+	return s.current(ctx, actor)
+}
+
+// Update меняет профиль. Новый email начинает действовать только после
+// перехода по ссылке из письма. После смены пароля все сессии, кроме
+// текущей (sessionID), завершаются.
+func (s *ProfileService) Update(ctx context.Context, actor Actor, sessionID string, upd ProfileUpdate) (*model.User, error) {
+	u, err := s.current(ctx, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	name, language := u.Name, u.Language
+	if upd.Name != nil {
+		name = strings.TrimSpace(*upd.Name)
+		if name == "" {
+			return nil, invalidf("name must not be empty")
+		}
+	}
+	if upd.Language != nil {
+		language = *upd.Language
+		if !validLanguage(language) {
+			return nil, invalidf("language must be one of %s, %s, %s", model.LanguageRussian, model.LanguageKazakh, model.LanguageEnglish)
+		}
+	}
+
+	var newEmail, newHash string
+	if upd.Email != nil {
+		newEmail = strings.TrimSpace(*upd.Email)
+		if newEmail == u.Email {
+			newEmail = ""
+		} else if !strings.Contains(newEmail, "@") {
+			return nil, invalidf("email is invalid")
+		}
+	}
+	if upd.Password != nil {
+		if len(*upd.Password) < MinPasswordLength {
+			return nil, invalidf("password must be at least %d characters", MinPasswordLength)
+		}
+		if *upd.Password == upd.CurrentPassword {
+			return nil, invalidf("new password must differ from the current one")
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(*upd.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		newHash = string(hash)
+	}
+	if newEmail != "" || newHash != "" {
+		if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(upd.CurrentPassword)) != nil {
+			return nil, ErrInvalidCredentials
+		}
+	}
+	if newEmail != "" {
+		owner, err := s.users.GetByEmail(ctx, newEmail)
+		if err != nil {
+			return nil, err
+		}
+		if owner != nil {
+			return nil, &ConflictError{Msg: "email already in use"}
+		}
+	}
+
+	if name != u.Name || language != u.Language {
+		if _, err := s.users.UpdateProfile(ctx, u.ID, name, language); err != nil {
+			return nil, err
+		}
+	}
+	if newHash != "" {
+		if err := s.users.SetPasswordHash(ctx, u.ID, newHash, false); err != nil {
+			return nil, err
+		}
+		n, err := s.logins.endOtherSessions(ctx, u.ID, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		s.audit.Record(ctx, actor, model.AuditPasswordChanged, "user", u.ID, map[string]interface{}{"sessionsRevoked": n})
+	}
+	if newEmail != "" {
+		if err := s.users.SetPendingEmail(ctx, u.ID, newEmail); err != nil {
+			return nil, err
+		}
+		if err := s.accounts.SendEmailChange(ctx, u, newEmail); err != nil {
+			return nil, err
+		}
+	}
+	return s.current(ctx, actor)
+}
+
+// Delete удаляет учётную запись покупателя после проверки пароля: личные
+// данные стираются, все сессии завершаются. Бронирования остаются для
+// отчётности. Пока есть неоплаченные заказы или билеты на будущие сеансы,
+// удаление запрещено — их нужно сначала отменить.
+func (s *ProfileService) Delete(ctx context.Context, actor Actor, password string) error {
+	u, err := s.current(ctx, actor)
+	if err != nil {
+		return err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) != nil {
+		return ErrInvalidCredentials
+	}
+	if u.Role != RoleCustomer {
+		return &ConflictError{Msg: "staff accounts are deactivated by an administrator"}
+	}
+
+	bookings, err := s.bookings.ByUser(ctx, u.ID)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	for _, b := range bookings {
+		if b.Status == model.BookingPending {
+			return &ConflictError{Msg: "booking awaits payment", Conflict: map[string]int{"bookingId": b.ID}}
+		}
+		if b.Status != model.BookingPaid {
+			continue
+		}
+		sess, err := s.sessions.GetByID(ctx, b.SessionID)
+		if err != nil {
+			return err
+		}
+		if sess != nil && sess.StartTime.After(now) {
+			return &ConflictError{Msg: "booking has upcoming tickets", Conflict: map[string]int{"bookingId": b.ID}}
+		}
+	}
+
+	if err := s.users.Anonymize(ctx, u.ID, now); err != nil {
+		return err
+	}
+	for _, purpose := range []string{model.TokenVerifyEmail, model.TokenResetPassword, model.TokenLogin2FA} {
+		if err := s.tokens.InvalidateForUser(ctx, u.ID, purpose, now); err != nil {
+			return err
+		}
+	}
+	if _, err := s.logins.endSessions(ctx, u.ID); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, actor, model.AuditAccountDeleted, "user", u.ID, nil)
+	return nil
+}
+
+// BookingSummary — бронирование в личном кабинете вместе с данными сеанса.
+type BookingSummary struct {
+	*model.Booking
+	StartTime  time.Time `json:"startTime"`
+	MovieID    int       `json:"movieId"`
+	MovieTitle string    `json:"movieTitle"`
+	HallName   string    `json:"hallName"`
+}
+
+// MyBookings — бронирования пользователя: предстоящие (оплаченные или
+// ожидающие оплаты на будущие сеансы, ближайшие первыми) и прошедшие вместе с
+// отменёнными (последние первыми).
+type MyBookings struct {
+	Upcoming []*BookingSummary `json:"upcoming"`
+	Past     []*BookingSummary `json:"past"`
+}
+
+// Bookings возвращает бронирования текущего пользователя. У оплаченных билетов
+// заполнены токены для QR-кодов.
+func (s *ProfileService) Bookings(ctx context.Context, actor Actor) (*MyBookings, error) {
+	bookings, err := s.bookings.ByUser(ctx, actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+	out := &MyBookings{Upcoming: []*BookingSummary{}, Past: []*BookingSummary{}}
+	sessions := map[int]*model.Session{}
+	movies := map[int]*model.Movie{}
+	halls := map[int]*model.Hall{}
+	now := time.Now()
+	for _, b := range bookings {
+		sess, ok := sessions[b.SessionID]
+		if !ok {
+			if sess, err = s.sessions.GetByID(ctx, b.SessionID); err != nil {
+				return nil, err
+			}
+			sessions[b.SessionID] = sess
+		}
+		issueTokens(s.signer, b)
+		item := &BookingSummary{Booking: b}
+		if sess != nil {
+			item.StartTime = sess.StartTime
+			item.MovieID = sess.MovieID
+			movie, ok := movies[sess.MovieID]
+			if !ok {
+				if movie, err = s.movies.GetByID(sess.MovieID); err != nil {
+					return nil, err
+				}
+				movies[sess.MovieID] = movie
+			}
+			if movie != nil {
+				item.MovieTitle = movie.Title
+			}
+			hall, ok := halls[sess.HallID]
+			if !ok {
+				if hall, err = s.halls.GetByID(ctx, sess.HallID); err != nil {
+					return nil, err
+				}
+				halls[sess.HallID] = hall
+			}
+			if hall != nil {
+				item.HallName = hall.Name
+			}
+		}
+
+		active := b.Status == model.BookingPaid || b.Status == model.BookingPending
+		if active && item.StartTime.After(now) {
+			out.Upcoming = append(out.Upcoming, item)
+		} else {
+			out.Past = append(out.Past, item)
+		}
+	}
+	sort.SliceStable(out.Upcoming, func(i, j int) bool { return out.Upcoming[i].StartTime.Before(out.Upcoming[j].StartTime) })
+	sort.SliceStable(out.Past, func(i, j int) bool { return out.Past[i].StartTime.After(out.Past[j].StartTime) })
+	return out, nil
+}
+
+// current загружает пользователя из токена. Удалённая или отключённая
+// учётная запись считается отсутствующей.
+func (s *ProfileService) current(ctx context.Context, actor Actor) (*model.User, error) {
+	u, err := s.users.GetByID(ctx, actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil || u.Disabled {
+		return nil, ErrNotFound
+	}
+	return u, nil
+}
+
+func validLanguage(lang string) bool {
+	switch lang {
+	case model.LanguageRussian, model.LanguageKazakh, model.LanguageEnglish:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"cinema-system/model"
+	"cinema-system/repository"
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultRoles — роли, которые создаются при первом запуске. Дальше их права
+// меняет администратор через /api/roles.
+var defaultRoles = []*model.Role{
+	{Name: RoleCustomer, Description: "Покупатель", Permissions: []string{}},
+	{Name: RoleCashier, Description: "Кассир", Permissions: []string{
+		model.PermBookingsManage, model.PermBookingsRefund, model.PermTicketsCheckIn,
+	}},
+	{Name: RoleUsher, Description: "Контролёр на входе в зал", Permissions: []string{
+		model.PermTicketsCheckIn,
+	}},
+	{Name: RoleAdmin, Description: "Администратор", Permissions: allPermissions()},
+}
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,31}$`)
+
+// RoleService хранит соответствие ролей и прав. Права читаются на каждом
+// запросе, поэтому держатся в памяти; после изменений и периодически
+// (Reload) кэш перечитывается из MongoDB.
+type RoleService struct {
+	repo  *repository.RoleRepo
+	users *repository.UserRepo
+	audit *AuditService
+
+	mu    sync.RWMutex
+	perms map[string][]string
+	mfa   map[string]bool // роли, которым обязателен второй фактор
+}
+
+func NewRoleService(repo *repository.RoleRepo, users *repository.UserRepo, audit *AuditService) *RoleService {
+	return &RoleService{repo: repo, users: users, audit: audit}
+}
+
+// EnsureDefaults создаёт недостающие встроенные роли и загружает права.
+func (s *RoleService) EnsureDefaults(ctx context.Context) error {
+	now := time.Now().UTC()
+	for _, r := range defaultRoles {
+		role := *r
+		role.BuiltIn = true
+		role.UpdatedAt = now
+		if err := s.repo.CreateIfMissing(ctx, &role); err != nil {
+			return err
+		}
+	}
+	return s.Reload(ctx)
+}
+
+// Reload перечитывает роли из базы.
+func (s *RoleService) Reload(ctx context.Context) error {
+	roles, err := s.repo.All(ctx)
+	if err != nil {
+		return err
+	}
+	perms := make(map[string][]string, len(roles))
+	mfa := make(map[string]bool)
+	for _, r := range roles {
+		perms[r.Name] = r.Permissions
+		if r.Require2FA {
+			mfa[r.Name] = true
+		}
+	}
+	s.mu.Lock()
+	s.perms = perms
+	s.mfa = mfa
+	s.mu.Unlock()
+	return nil
+}
+
+// Permissions возвращает права роли; у неизвестной роли прав нет.
+func (s *RoleService) Permissions(role string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.perms[role]
+}
+
+// Requires2FA сообщает, обязателен ли роли второй фактор при входе.
+func (s *RoleService) Requires2FA(role string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.mfa[role]
+}
+
+// Exists сообщает, заведена ли роль.
+func (s *RoleService) Exists(role string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.perms[role]
+	return ok
+}
+
+// List возвращает все роли.
+func (s *RoleService) List(ctx context.Context) ([]*model.Role, error) {
+	roles, err := s.repo.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if roles == nil {
+		roles = []*model.Role{}
+	}
+	return roles, nil
+}
+
+// Save создаёт роль или меняет её описание, права и обязательность второго
+// фактора. Права действуют сразу, в том числе для уже выданных токенов: они
+// берутся по роли на каждом запросе. Второй фактор требуется со следующего входа.
+func (s *RoleService) Save(ctx context.Context, actor Actor, name, description string, permissions []string, require2FA bool) (*model.Role, error) {
+	name = strings.TrimSpace(name)
+	if !roleNamePattern.MatchString(name) {
+		return nil, invalidf("role name must be 2-32 lowercase letters, digits, '-' or '_'")
+	}
+	perms, err := normalizePermissions(permissions)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.repo.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	role := &model.Role{
+		Name:        name,
+		Description: strings.TrimSpace(description),
+		Permissions: perms,
+		Require2FA:  require2FA,
+		UpdatedAt:   time.Now().UTC(),
+	}
+	if existing != nil {
+		role.BuiltIn = existing.BuiltIn
+	}
+	// Иначе администраторы потеряют доступ к настройке ролей.
+	if name == RoleAdmin && !contains(perms, model.PermRolesManage) {
+		return nil, invalidf("role %q must keep permission %q", RoleAdmin, model.PermRolesManage)
+	}
+	if err := s.repo.Save(ctx, role); err != nil {
+		return nil, err
+	}
+	if err := s.Reload(ctx); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, actor, model.AuditRoleSaved, "role", 0, map[string]interface{}{"role": name, "permissions": perms, "require2fa": require2FA})
+	return role, nil
+}
+
+// Delete удаляет роль, которая никому не назначена. Встроенные роли не удаляются.
+func (s *RoleService) Delete(ctx context.Context, actor Actor, name string) error {
+	role, err := s.repo.GetByName(ctx, name)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return ErrNotFound
+	}
+	if role.BuiltIn {
+		return &ConflictError{Msg: "built-in role cannot be deleted"}
+	}
+	n, err := s.users.CountByRole(ctx, name)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return &ConflictError{Msg: "role is assigned to users; change their role first"}
+	}
+	if _, err := s.repo.Delete(ctx, name); err != nil {
+		return err
+	}
+	if err := s.Reload(ctx); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, actor, model.AuditRoleDeleted, "role", 0, map[string]interface{}{"role": name})
+	return nil
+}
+
+// normalizePermissions проверяет, что все права известны, и убирает повторы.
+func normalizePermissions(permissions []string) ([]string, error) {
+	known := make(map[string]bool, len(model.Permissions))
+	for _, p := range model.Permissions {
+		known[p.Name] = true
+	}
+	seen := make(map[string]bool, len(permissions))
+	out := []string{}
+	for _, p := range permissions {
+		if !known[p] {
+			return nil, invalidf("unknown permission %q", p)
+		}
+		if !seen[p] {
+			seen[p] = true
+			out = append(out, p)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func allPermissions() []string {
+	out := make([]string, len(model.Permissions))
+	for i, p := range model.Permissions {
+		out[i] = p.Name
+	}
+	return out
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
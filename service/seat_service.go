@@ -0,0 +1,82 @@
+package service
+
+import (
+	"cinema-system/model"
+	"cinema-system/repository"
+	"context"
+	"time"
+)
+
+// SeatService собирает схему зала с актуальным состоянием мест на сеанс.
+type SeatService struct {
+	sessions *repository.SessionRepo
+	halls    *repository.HallRepo
+	bookings *repository.BookingRepo
+	holds    *repository.HoldRepo
+}
+
+func NewSeatService(sessions *repository.SessionRepo, halls *repository.HallRepo, bookings *repository.BookingRepo, holds *repository.HoldRepo) *SeatService {
+	return &SeatService{sessions: sessions, halls: halls, bookings: bookings, holds: holds}
+}
+
+// SeatMap возвращает схему зала сеанса: проданные, удержанные, заблокированные,
+// свободные места и свободные места для маломобильных зрителей.
+func (s *SeatService) SeatMap(ctx context.Context, sessionID int) (*model.SeatMap, error) {
+	sess, err := s.sessions.GetByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if sess == nil {
+		return nil, ErrNotFound
+	}
+	hall, err := s.halls.GetByID(ctx, sess.HallID)
+	if err != nil {
+		return nil, err
+	}
+	if hall == nil {
+		return nil, ErrNotFound
+	}
+
+	sold, err := s.bookings.SoldSeats(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	held, err := s.holds.HeldSeats(ctx, sessionID, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	state := make(map[model.SeatRef]string, len(sold)+len(held))
+	for _, ref := range held {
+		state[ref] = model.SeatStateHeld
+	}
+	for _, ref := range sold {
+		state[ref] = model.SeatStateSold
+	}
+
+	m := &model.SeatMap{SessionID: sess.ID, HallID: hall.ID, HallName: hall.Name}
+	for _, row := range hall.Rows {
+		out := model.SeatMapRow{Label: row.Label, Seats: make([]model.SeatState, 0, len(row.Seats))}
+		for _, seat := range row.Seats {
+			cell := model.SeatState{Seat: seat}
+			if seat.Kind == model.SeatKindSeat {
+				cell.State = seatState(seat, state[model.SeatRef{Row: row.Label, Seat: seat.Number}])
+			}
+			out.Seats = append(out.Seats, cell)
+		}
+		m.Rows = append(m.Rows, out)
+	}
+	return m, nil
+}
+
+func seatState(seat model.Seat, occupied string) string {
+	switch {
+	case occupied != "":
+		return occupied
+	case seat.Blocked:
+		return model.SeatStateBlocked
+	case seat.Category == model.SeatCategoryAccessible:
+		return model.SeatStateAccessible
+	default:
+		return model.SeatStateFree
+	}
+}
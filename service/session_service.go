@@ -0,0 +1,235 @@
+package service
+
+import (
+	"cinema-system/model"
+	"cinema-system/repository"
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultBasePrice — базовая цена билета, если админ её не указал, ₸.
+const DefaultBasePrice = 2500
+
+// SessionService — расписание сеансов.
+type SessionService struct {
+	repo     *repository.SessionRepo
+	movies   *repository.MovieRepo
+	halls    *repository.HallRepo
+	bookings *repository.BookingRepo
+	loc      *time.Location
+	buffer   time.Duration
+}
+
+// NewSessionService создаёт сервис расписания. loc — часовой пояс кинотеатра,
+// в нём интерпретируется фильтр по дате; buffer — минимальный перерыв на уборку
+// зала между сеансами.
+func NewSessionService(repo *repository.SessionRepo, movies *repository.MovieRepo, halls *repository.HallRepo, bookings *repository.BookingRepo, loc *time.Location, buffer time.Duration) *SessionService {
+	return &SessionService{repo: repo, movies: movies, halls: halls, bookings: bookings, loc: loc, buffer: buffer}
+}
+
+// SessionQuery — фильтр списка сеансов. Date в формате YYYY-MM-DD (пусто — любые даты).
+type SessionQuery struct {
+	Date    string
+	MovieID int
+	HallID  int
+}
+
+// Create планирует новый сеанс; время окончания считается по длительности фильма.
+// Если зал в это время занят (с учётом уборки), возвращается ConflictError.
+func (s *SessionService) Create(ctx context.Context, sess *model.Session) (*model.Session, error) {
+	if err := s.prepare(ctx, sess); err != nil {
+		return nil, err
+	}
+	conflict, err := s.repo.CreateIfFree(ctx, sess, s.buffer)
+	if err != nil {
+		return nil, err
+	}
+	if conflict != nil {
+		return nil, hallBusy(conflict)
+	}
+	return sess, nil
+}
+
+// GetByID возвращает сеанс по id.
+func (s *SessionService) GetByID(ctx context.Context, id int) (*model.Session, error) {
+	sess, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sess == nil {
+		return nil, ErrNotFound
+	}
+	return sess, nil
+}
+
+// List возвращает сеансы по фильтру.
+func (s *SessionService) List(ctx context.Context, q SessionQuery) ([]*model.Session, error) {
+	f := repository.SessionFilter{MovieID: q.MovieID, HallID: q.HallID}
+	if q.Date != "" {
+		day, err := time.ParseInLocation("2006-01-02", q.Date, s.loc)
+		if err != nil {
+			return nil, invalidf("date must be in YYYY-MM-DD format")
+		}
+		f.From = day
+		f.To = day.AddDate(0, 0, 1)
+	}
+	return s.repo.Find(ctx, f)
+}
+
+// Update перепланирует сеанс. Сеанс с проданными или ожидающими оплаты
+// билетами не меняется: ConflictError.
+func (s *SessionService) Update(ctx context.Context, sess *model.Session) error {
+	if err := s.prepare(ctx, sess); err != nil {
+		return err
+	}
+	if err := s.checkNoBookings(ctx, sess.ID); err != nil {
+		return err
+	}
+	conflict, found, err := s.repo.UpdateIfFree(ctx, sess, s.buffer)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrNotFound
+	}
+	if conflict != nil {
+		return hallBusy(conflict)
+	}
+	return nil
+}
+
+func hallBusy(conflict *model.Session) error {
+	return &ConflictError{
+		Msg:      fmt.Sprintf("hall %d is busy: overlaps session %d", conflict.HallID, conflict.ID),
+		Conflict: conflict,
+	}
+}
+
+// Delete удаляет сеанс. Сеанс с проданными или ожидающими оплаты билетами не
+// удаляется: ConflictError.
+func (s *SessionService) Delete(ctx context.Context, id int) error {
+	if err := s.checkNoBookings(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+// checkNoBookings возвращает ConflictError, если на сеанс есть неотменённые
+// бронирования: их сначала нужно отменить с возвратом денег.
+func (s *SessionService) checkNoBookings(ctx context.Context, id int) error {
+	n, err := s.bookings.CountActiveBySession(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return &ConflictError{Msg: "session has bookings; cancel them first"}
+	}
+	return nil
+}
+
+// prepare проверяет фильм и зал, заполняет значения по умолчанию и EndTime.
+func (s *SessionService) prepare(ctx context.Context, sess *model.Session) error {
+	if sess.StartTime.IsZero() {
+		return invalidf("startTime is required")
+	}
+	if sess.BasePrice < 0 {
+		return invalidf("basePrice must not be negative")
+	}
+	if sess.BasePrice == 0 {
+		sess.BasePrice = DefaultBasePrice
+	}
+
+	movie, err := s.movies.GetByID(sess.MovieID)
+	if err != nil {
+		return err
+	}
+	if movie == nil {
+		return invalidf("movie %d does not exist", sess.MovieID)
+	}
+	if movie.Duration <= 0 {
+		return invalidf("movie %d has no duration", sess.MovieID)
+	}
+	if movie.Status == model.MovieArchived {
+		return invalidf("movie %d is archived", sess.MovieID)
+	}
+
+	hall, err := s.halls.GetByID(ctx, sess.HallID)
+	if err != nil {
+		return err
+	}
+	if hall == nil {
+		return invalidf("hall %d does not exist", sess.HallID)
+	}
+
+	sess.StartTime = sess.StartTime.UTC()
+	sess.EndTime = sess.StartTime.Add(time.Duration(movie.Duration) * time.Minute)
+	return nil
+}
+
+// SeedDemoSchedule заполняет расписание на ближайшие days дней, если в какой-то
+// из этих дней нет ни одного сеанса: в каждом зале фильмы идут друг за другом
+// с 10:00 до позднего вечера. Нужен для демо-стенда, чтобы афиша не была пустой.
+// В расписание попадают только фильмы, которые сейчас в прокате.
+func (s *SessionService) SeedDemoSchedule(ctx context.Context, days int) error {
+	all, err := s.movies.GetAll()
+	if err != nil {
+		return err
+	}
+	var movies []*model.Movie
+	for _, m := range all {
+		if m.Status == model.MovieNowShowing {
+			movies = append(movies, m)
+		}
+	}
+	if len(movies) == 0 {
+		return nil
+	}
+	halls, err := s.halls.GetAll(ctx)
+	if err != nil || len(halls) == 0 {
+		return err
+	}
+
+	gap := 20 * time.Minute
+	if s.buffer > gap {
+		gap = s.buffer
+	}
+
+	now := time.Now().In(s.loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.loc)
+	for d := 0; d < days; d++ {
+		day := today.AddDate(0, 0, d)
+		existing, err := s.repo.Find(ctx, repository.SessionFilter{From: day, To: day.AddDate(0, 0, 1)})
+		if err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			continue
+		}
+
+		for hi, h := range halls {
+			start := day.Add(10 * time.Hour)
+			last := day.Add(22 * time.Hour)
+			for k := 0; !start.After(last); k++ {
+				m := movies[(hi+k+d)%len(movies)]
+				if m.Duration <= 0 {
+					start = start.Add(15 * time.Minute)
+					continue
+				}
+				sess := &model.Session{
+					MovieID:   m.ID,
+					HallID:    h.ID,
+					StartTime: start,
+					Language:  "ru",
+					Format:    "2D",
+				}
+				if _, err := s.Create(ctx, sess); err != nil {
+					return err
+				}
+				// Следующий сеанс — после уборки, с округлением до 15 минут.
+				start = sess.EndTime.In(s.loc).Add(gap).Truncate(15 * time.Minute).Add(15 * time.Minute)
+			}
+		}
+	}
+	return nil
+}
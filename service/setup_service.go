@@ -0,0 +1,146 @@
+package service
+
+import (
+	"cinema-system/model"
+	"cinema-system/repository"
+	"context"
+	"crypto/subtle"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// SetupService создаёт первого администратора. Учётных данных по умолчанию
+// нет: администратор берётся из конфигурации (с обязательной сменой пароля
+// при первом входе) либо создаётся по одноразовому токену, который сервер
+// печатает в лог, пока в системе нет ни одного администратора.
+type SetupService struct {
+	users  *repository.UserRepo
+	tokens *TokenService
+	audit  *AuditService
+
+	mu        sync.Mutex
+	tokenHash string // пусто — настройка не требуется или уже выполнена
+}
+
+func NewSetupService(users *repository.UserRepo, tokens *TokenService, audit *AuditService) *SetupService {
+	return &SetupService{users: users, tokens: tokens, audit: audit}
+}
+
+// legacyAccounts — учётные записи, которые прежние версии создавали при каждом
+// старте с паролем legacyPassword. Такие пароли известны всем, кто видел код.
+var legacyAccounts = []string{"admin", "cashier"}
+
+const legacyPassword = "1234"
+
+// isLegacyDefault сообщает, что у пользователя всё ещё учётные данные по
+// умолчанию из прежних версий.
+func isLegacyDefault(u *model.User) bool {
+	if u == nil || !contains(legacyAccounts, strings.ToLower(u.Email)) {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(legacyPassword)) == nil
+}
+
+// DisableLegacyAccounts отключает учётные записи admin и cashier, если их
+// пароль так и остался 1234, и завершает их сессии. Вызывается при старте до
+// Bootstrap: если других администраторов нет, сервер предложит создать нового.
+// Возвращает число отключённых записей.
+func (s *SetupService) DisableLegacyAccounts(ctx context.Context) (int, error) {
+	n := 0
+	for _, email := range legacyAccounts {
+		u, err := s.users.GetByEmail(ctx, email)
+		if err != nil {
+			return n, err
+		}
+		if u == nil || u.Disabled || !isLegacyDefault(u) {
+			continue
+		}
+		if _, err := s.users.SetDisabled(ctx, u.ID, true); err != nil {
+			return n, err
+		}
+		if _, err := s.tokens.endSessions(ctx, u.ID); err != nil {
+			return n, err
+		}
+		s.audit.Record(ctx, Actor{}, model.AuditUserDeactivated, "user", u.ID, map[string]interface{}{"reason": "default credentials"})
+		n++
+	}
+	return n, nil
+}
+
+// Bootstrap вызывается при старте. Если включённый администратор уже есть,
+// ничего не делает. Если заданы email и пароль, создаёт администратора с ними. Иначе
+// возвращает одноразовый токен для POST /api/setup.
+func (s *SetupService) Bootstrap(ctx context.Context, email, password string) (string, error) {
+	n, err := s.users.CountEnabledByRole(ctx, RoleAdmin)
+	if err != nil || n > 0 {
+		return "", err
+	}
+	if email != "" && password != "" {
+		u, err := s.create(ctx, "Admin", email, password, true)
+		if err != nil {
+			return "", err
+		}
+		s.audit.Record(ctx, Actor{}, model.AuditAdminBootstrapped, "user", u.ID, map[string]interface{}{"source": "config"})
+		return "", nil
+	}
+	token := randomToken(24)
+	s.mu.Lock()
+	s.tokenHash = hashToken(token)
+	s.mu.Unlock()
+	return token, nil
+}
+
+// Complete создаёт первого администратора по токену из лога. Токен
+// срабатывает один раз.
+func (s *SetupService) Complete(ctx context.Context, token, name, email, password string) (*model.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tokenHash == "" || subtle.ConstantTimeCompare([]byte(hashToken(token)), []byte(s.tokenHash)) != 1 {
+		return nil, invalidf("setup token is invalid or already used")
+	}
+	n, err := s.users.CountEnabledByRole(ctx, RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if n > 0 {
+		s.tokenHash = ""
+		return nil, &ConflictError{Msg: "an administrator already exists"}
+	}
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return nil, invalidf("email is required")
+	}
+	if len(password) < MinPasswordLength {
+		return nil, invalidf("password must be at least %d characters", MinPasswordLength)
+	}
+	u, err := s.create(ctx, strings.TrimSpace(name), email, password, false)
+	if err != nil {
+		return nil, err
+	}
+	s.tokenHash = ""
+	s.audit.Record(ctx, Actor{UserID: u.ID, Role: u.Role}, model.AuditAdminBootstrapped, "user", u.ID, map[string]interface{}{"source": "setup_token"})
+	return u, nil
+}
+
+func (s *SetupService) create(ctx context.Context, name, email, password string, mustChange bool) (*model.User, error) {
+	existing, err := s.users.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, &ConflictError{Msg: "email " + email + " already in use"}
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	return s.users.Create(ctx, &model.User{
+		Email:              email,
+		PasswordHash:       string(hash),
+		Name:               name,
+		Role:               RoleAdmin,
+		MustChangePassword: mustChange,
+	})
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"cinema-system/model"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestIsLegacyDefault(t *testing.T) {
+	hash := func(password string) string {
+		h, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(h)
+	}
+	tests := []struct {
+		name string
+		user *model.User
+		want bool
+	}{
+		{"admin with default password", &model.User{Email: "admin", PasswordHash: hash("1234")}, true},
+		{"cashier with default password", &model.User{Email: "cashier", PasswordHash: hash("1234")}, true},
+		{"email case is ignored", &model.User{Email: "Admin", PasswordHash: hash("1234")}, true},
+		{"admin with changed password", &model.User{Email: "admin", PasswordHash: hash("correct horse")}, false},
+		{"other account with 1234", &model.User{Email: "alice@example.com", PasswordHash: hash("1234")}, false},
+		{"no user", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isLegacyDefault(tt.user); got != tt.want {
+				t.Errorf("isLegacyDefault = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
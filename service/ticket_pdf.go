@@ -0,0 +1,257 @@
+package service
+
+import (
+	"cinema-system/eticket"
+	"cinema-system/model"
+	"cinema-system/pdf"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ticketTypeNames — подписи типов билетов в документах.
+var ticketTypeNames = map[string]string{
+	model.TicketAdult:   "Взрослый",
+	model.TicketStudent: "Студенческий",
+	model.TicketChild:   "Детский",
+}
+
+// ticketDocument — всё, что печатается на билетах и в чеке бронирования.
+type ticketDocument struct {
+	booking *model.Booking
+	session *model.Session
+	movie   string
+	hall    string
+}
+
+// loadDocument загружает бронирование с сеансом, фильмом и залом. Покупатель
+// видит только свои бронирования; документы выдаются лишь по оплаченным
+// (чек — ещё и по отменённым после оплаты, с суммой возврата).
+func (s *TicketService) loadDocument(ctx context.Context, actor Actor, bookingID int, allowCancelled bool) (*ticketDocument, error) {
+	b, err := s.bookings.GetByID(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil || (b.UserID != actor.UserID && !actor.Can(model.PermBookingsManage)) {
+		return nil, ErrNotFound
+	}
+	paidThenCancelled := b.Status == model.BookingCancelled && b.PaidAt != nil
+	if b.Status != model.BookingPaid && !(allowCancelled && paidThenCancelled) {
+		return nil, &ConflictError{Msg: "booking is " + b.Status + ", not paid"}
+	}
+	sess, err := s.sessions.GetByID(ctx, b.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if sess == nil {
+		return nil, ErrNotFound
+	}
+	doc := &ticketDocument{booking: b, session: sess, movie: fmt.Sprintf("Фильм №%d", sess.MovieID), hall: fmt.Sprintf("Зал №%d", sess.HallID)}
+	movie, err := s.movies.GetByID(sess.MovieID)
+	if err != nil {
+		return nil, err
+	}
+	if movie != nil {
+		doc.movie = movie.Title
+	}
+	hall, err := s.halls.GetByID(ctx, sess.HallID)
+	if err != nil {
+		return nil, err
+	}
+	if hall != nil {
+		doc.hall = hall.Name
+	}
+	return doc, nil
+}
+
+// TicketPDF возвращает PDF с билетами оплаченного бронирования: по странице
+// A5 на билет с фильмом, залом, местом, временем, ценой и QR-кодом для входа.
+func (s *TicketService) TicketPDF(ctx context.Context, actor Actor, bookingID int) ([]byte, error) {
+	doc, err := s.loadDocument(ctx, actor, bookingID, false)
+	if err != nil {
+		return nil, err
+	}
+	d, err := pdf.New()
+	if err != nil {
+		return nil, err
+	}
+	d.SetTitle(fmt.Sprintf("Билеты — бронь №%d", doc.booking.ID))
+
+	start := doc.session.StartTime.In(s.loc)
+	for _, t := range doc.booking.Tickets {
+		if t.Cancelled {
+			continue
+		}
+		p := d.AddPage(pdf.A5Width, pdf.A5Height)
+		const margin = 36.0
+		width := p.Width() - 2*margin
+
+		p.SetGray(0.45)
+		p.Text(margin, 48, 10, "CINEMA SYSTEM · ЭЛЕКТРОННЫЙ БИЛЕТ")
+		p.SetGray(0)
+		y := 80.0
+		for _, line := range wrapText(p, doc.movie, 20, width) {
+			p.Text(margin, y, 20, line)
+			y += 24
+		}
+		p.Line(margin, y-8, margin+width, y-8, 0.5)
+
+		y += 14
+		rows := [][2]string{
+			{"Сеанс", start.Format("02.01.2006, 15:04")},
+			{"Зал", doc.hall},
+			{"Место", fmt.Sprintf("ряд %s, место %d", t.Row, t.Seat)},
+			{"Билет", ticketTypeName(t.Type)},
+			{"Цена", formatTenge(t.Price)},
+		}
+		for _, row := range rows {
+			p.SetGray(0.45)
+			p.Text(margin, y, 10, row[0])
+			p.SetGray(0)
+			p.Text(margin+70, y, 13, row[1])
+			y += 22
+		}
+
+		const qrSize = 190.0
+		token := s.signer.Sign(eticket.Claims{TicketID: t.ID, SessionID: t.SessionID})
+		code, err := eticket.QR(token)
+		if err != nil {
+			return nil, err
+		}
+		qrX := (p.Width() - qrSize) / 2
+		p.Modules(qrX, y+10, qrSize, code.Size, code.Black)
+		y += qrSize + 30
+
+		p.SetGray(0.45)
+		caption := fmt.Sprintf("Бронь №%d · билет №%d · покажите код на входе в зал", doc.booking.ID, t.ID)
+		p.Text((p.Width()-p.TextWidth(9, caption))/2, y, 9, caption)
+		p.SetGray(0)
+	}
+	return d.Bytes()
+}
+
+// ReceiptPDF возвращает чек по бронированию для печати на кассовом принтере
+// (лента 80 мм): все билеты, итог, оплата и возврат, если бронь отменена.
+func (s *TicketService) ReceiptPDF(ctx context.Context, actor Actor, bookingID int) ([]byte, error) {
+	doc, err := s.loadDocument(ctx, actor, bookingID, true)
+	if err != nil {
+		return nil, err
+	}
+	b := doc.booking
+	d, err := pdf.New()
+	if err != nil {
+		return nil, err
+	}
+	d.SetTitle(fmt.Sprintf("Чек — бронь №%d", b.ID))
+
+	const (
+		pageWidth = 226.77 // 80 мм
+		margin    = 12.0
+		lineStep  = 13.0
+	)
+	width := pageWidth - 2*margin
+	// Высота ленты зависит от числа строк: шапка, билеты, итоги.
+	height := 260 + float64(len(b.Tickets))*lineStep
+	p := d.AddPage(pageWidth, height)
+
+	center := func(y, size float64, s string) {
+		p.Text((pageWidth-p.TextWidth(size, s))/2, y, size, s)
+	}
+	pair := func(y float64, left, right string) {
+		p.Text(margin, y, 8, left)
+		p.TextRight(pageWidth-margin, y, 8, right)
+	}
+
+	y := 24.0
+	center(y, 11, "CINEMA SYSTEM")
+	y += lineStep
+	center(y, 8, fmt.Sprintf("Чек по брони №%d", b.ID))
+	y += lineStep * 1.5
+
+	for _, line := range wrapText(p, doc.movie, 9, width) {
+		p.Text(margin, y, 9, line)
+		y += lineStep
+	}
+	pair(y, "Сеанс", doc.session.StartTime.In(s.loc).Format("02.01.2006 15:04"))
+	y += lineStep
+	pair(y, "Зал", doc.hall)
+	y += lineStep
+	p.Line(margin, y-6, pageWidth-margin, y-6, 0.4)
+	y += lineStep / 2
+
+	for _, t := range b.Tickets {
+		pair(y, fmt.Sprintf("Ряд %s, место %d · %s", t.Row, t.Seat, strings.ToLower(ticketTypeName(t.Type))), formatTenge(t.Price))
+		y += lineStep
+	}
+	p.Line(margin, y-6, pageWidth-margin, y-6, 0.4)
+	y += lineStep / 2
+
+	p.Text(margin, y, 10, "ИТОГО")
+	p.TextRight(pageWidth-margin, y, 10, formatTenge(b.Total))
+	y += lineStep * 1.5
+	if b.PaidAt != nil {
+		pair(y, "Оплачено", b.PaidAt.In(s.loc).Format("02.01.2006 15:04"))
+		y += lineStep
+	}
+	if b.Status == model.BookingCancelled {
+		if b.CancelledAt != nil {
+			pair(y, "Отменено", b.CancelledAt.In(s.loc).Format("02.01.2006 15:04"))
+			y += lineStep
+		}
+		pair(y, "Возврат", formatTenge(b.RefundAmount))
+		y += lineStep
+	}
+	y += lineStep / 2
+	p.SetGray(0.45)
+	center(y, 7, "Спасибо за покупку!")
+	return d.Bytes()
+}
+
+func ticketTypeName(t string) string {
+	if name, ok := ticketTypeNames[t]; ok {
+		return name
+	}
+	return t
+}
+
+// formatTenge форматирует сумму с разделением разрядов: «12 500 ₸».
+func formatTenge(amount int) string {
+	digits := strconv.Itoa(amount)
+	neg := strings.HasPrefix(digits, "-")
+	digits = strings.TrimPrefix(digits, "-")
+	var b strings.Builder
+	if neg {
+		b.WriteString("-")
+	}
+	for i, r := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteString(" ₸")
+	return b.String()
+}
+
+// wrapText разбивает текст на строки не шире width по границам слов.
+func wrapText(p *pdf.Page, text string, size, width float64) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if line != "" && p.TextWidth(size, candidate) > width {
+			lines = append(lines, line)
+			line = word
+			continue
+		}
+		line = candidate
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"cinema-system/eticket"
+	"cinema-system/model"
+	"cinema-system/repository"
+	"context"
+	"errors"
+	"time"
+)
+
+// TicketService — электронные билеты: QR-коды, PDF и проход в зал.
+type TicketService struct {
+	bookings    *repository.BookingRepo
+	sessions    *repository.SessionRepo
+	movies      *repository.MovieRepo
+	halls       *repository.HallRepo
+	signer      *eticket.Signer
+	loc         *time.Location
+	opensBefore time.Duration
+}
+
+// NewTicketService создаёт сервис билетов. opensBefore — за сколько до начала
+// сеанса контролёр начинает пускать в зал.
+func NewTicketService(bookings *repository.BookingRepo, sessions *repository.SessionRepo, movies *repository.MovieRepo, halls *repository.HallRepo, signer *eticket.Signer, loc *time.Location, opensBefore time.Duration) *TicketService {
+	return &TicketService{bookings: bookings, sessions: sessions, movies: movies, halls: halls, signer: signer, loc: loc, opensBefore: opensBefore}
+}
+
+// issueTokens проставляет токены билетам оплаченного бронирования.
+func issueTokens(signer *eticket.Signer, b *model.Booking) {
+	if b.Status != model.BookingPaid {
+		return
+	}
+	for _, t := range b.Tickets {
+		if !t.Cancelled {
+			t.Token = signer.Sign(eticket.Claims{TicketID: t.ID, SessionID: t.SessionID})
+		}
+	}
+}
+
+// QR возвращает PNG с QR-кодом билета владельцу бронирования или сотруднику.
+func (s *TicketService) QR(ctx context.Context, actor Actor, ticketID int) ([]byte, error) {
+	t, err := s.bookings.GetTicket(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, ErrNotFound
+	}
+	b, err := s.bookings.GetByID(ctx, t.BookingID)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil || (b.UserID != actor.UserID && !actor.Can(model.PermBookingsManage)) {
+		return nil, ErrNotFound
+	}
+	if b.Status != model.BookingPaid || t.Cancelled {
+		return nil, &ConflictError{Msg: "ticket is not valid: booking is " + b.Status}
+	}
+	return eticket.QRPNG(s.signer.Sign(eticket.Claims{TicketID: t.ID, SessionID: t.SessionID}))
+}
+
+// CheckInResult — пропущенный билет. IDCheck означает, что фильм 16+/18+ и
+// контролёр должен проверить документ зрителя.
+type CheckInResult struct {
+	*model.Ticket
+	AgeRating string `json:"ageRating,omitempty"`
+	IDCheck   bool   `json:"idCheck"`
+}
+
+// CheckIn проверяет токен билета на входе в зал сеанса sessionID и отмечает
+// билет использованным. Отказывает, если подпись не сходится, билет на другой
+// сеанс, вход ещё не открыт или сеанс закончился, билет отменён или уже
+// использован.
+func (s *TicketService) CheckIn(ctx context.Context, actor Actor, token string, sessionID int) (*CheckInResult, error) {
+	claims, err := s.signer.Parse(token)
+	if errors.Is(err, eticket.ErrInvalidToken) {
+		return nil, invalidf("invalid ticket")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if sessionID == 0 {
+		return nil, invalidf("sessionId is required")
+	}
+	if claims.SessionID != sessionID {
+		return nil, &ConflictError{Msg: "ticket is for another session", Conflict: map[string]int{"sessionId": claims.SessionID}}
+	}
+
+	t, err := s.bookings.GetTicket(ctx, claims.TicketID)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil || t.SessionID != claims.SessionID {
+		return nil, invalidf("invalid ticket")
+	}
+	b, err := s.bookings.GetByID(ctx, t.BookingID)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil || b.Status != model.BookingPaid || t.Cancelled {
+		return nil, &ConflictError{Msg: "ticket is not valid", Conflict: t}
+	}
+	if t.CheckedInAt != nil {
+		return nil, &ConflictError{Msg: "ticket has already been used", Conflict: t}
+	}
+
+	sess, err := s.sessions.GetByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if sess == nil {
+		return nil, ErrNotFound
+	}
+	now := time.Now().UTC()
+	if opens := sess.StartTime.Add(-s.opensBefore); now.Before(opens) {
+		return nil, &ConflictError{Msg: "check-in opens at " + opens.In(s.loc).Format("15:04")}
+	}
+	if !now.Before(sess.EndTime) {
+		return nil, &ConflictError{Msg: "session is over"}
+	}
+
+	movie, err := s.movies.GetByID(sess.MovieID)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := s.bookings.CheckIn(ctx, t.ID, actor.UserID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// Билет успели отсканировать на другом входе.
+		return nil, &ConflictError{Msg: "ticket has already been used"}
+	}
+	t.CheckedInAt = &now
+	t.CheckedInBy = actor.UserID
+
+	res := &CheckInResult{Ticket: t}
+	if movie != nil {
+		res.AgeRating = movie.AgeRating
+		res.IDCheck = model.AgeRestricted(movie.AgeRating)
+	}
+	return res, nil
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"cinema-system/model"
+	"cinema-system/repository"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrInvalidRefreshToken — refresh-токен неизвестен, истёк, отозван или уже
+// был использован.
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+// TokenPair — выданные клиенту токены.
+type TokenPair struct {
+	AccessToken  string    `json:"token"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+	RefreshToken string    `json:"refreshToken"`
+}
+
+// ClientInfo — откуда выполнен вход (для списка сессий и аудита).
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+// TokenService выдаёт короткие access-токены (JWT) и ротируемые refresh-токены,
+// привязанные к сессии входа в MongoDB. Отзыв сессии сразу делает
+// недействительными и её refresh-токен, и все выданные по ней access-токены.
+type TokenService struct {
+	sessions   *repository.AuthSessionRepo
+	users      *repository.UserRepo
+	audit      *AuditService
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+func NewTokenService(sessions *repository.AuthSessionRepo, users *repository.UserRepo, audit *AuditService, jwtSecret string, accessTTL, refreshTTL time.Duration) *TokenService {
+	return &TokenService{sessions: sessions, users: users, audit: audit, secret: []byte(jwtSecret), accessTTL: accessTTL, refreshTTL: refreshTTL}
+}
+
+// Issue открывает новую сессию для пользователя и выдаёт пару токенов.
+func (s *TokenService) Issue(ctx context.Context, u *model.User, client ClientInfo) (*TokenPair, error) {
+	now := time.Now().UTC()
+	secret := randomToken(32)
+	sess := &model.AuthSession{
+		ID:         randomToken(16),
+		UserID:     u.ID,
+		TokenHash:  hashToken(secret),
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(s.refreshTTL),
+	}
+	if err := s.sessions.Create(ctx, sess); err != nil {
+		return nil, err
+	}
+	return s.pair(u, sess.ID, secret, now)
+}
+
+// Refresh обменивает refresh-токен на новую пару токенов. Старый refresh-токен
+// после этого недействителен. Повторное предъявление уже использованного
+// токена означает его утечку — тогда сессия отзывается целиком.
+func (s *TokenService) Refresh(ctx context.Context, refreshToken string) (*TokenPair, *model.User, error) {
+	sid, secret, ok := strings.Cut(refreshToken, ".")
+	if !ok || sid == "" || secret == "" {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	sess, err := s.sessions.GetByID(ctx, sid)
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now().UTC()
+	if sess == nil || sess.RevokedAt != nil || !now.Before(sess.ExpiresAt) {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	newSecret := randomToken(32)
+	rotated, err := s.sessions.Rotate(ctx, sid, hashToken(secret), hashToken(newSecret), now)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !rotated {
+		if err := s.sessions.Revoke(ctx, sid, now); err != nil {
+			return nil, nil, err
+		}
+		s.audit.Record(ctx, Actor{UserID: sess.UserID}, model.AuditRefreshTokenReused, "user", sess.UserID, map[string]interface{}{"session": sid})
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	u, err := s.users.GetByID(ctx, sess.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if u == nil || u.Disabled {
+		_ = s.sessions.Revoke(ctx, sid, now)
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	pair, err := s.pair(u, sid, newSecret, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pair, u, nil
+}
+
+// Logout отзывает сессию, к которой относится refresh-токен или access-токен
+// (по claim "sid").
+func (s *TokenService) Logout(ctx context.Context, sessionID string) error {
+	if sessionID == "" {
+		return invalidf("nothing to log out")
+	}
+	return s.sessions.Revoke(ctx, sessionID, time.Now().UTC())
+}
+
+// SessionFromRefreshToken возвращает id сессии из refresh-токена.
+func SessionFromRefreshToken(refreshToken string) string {
+	sid, _, _ := strings.Cut(refreshToken, ".")
+	return sid
+}
+
+// RevokeAll отзывает все сессии пользователя (например, уволенного кассира):
+// его токены перестают приниматься сразу, без ожидания истечения.
+func (s *TokenService) RevokeAll(ctx context.Context, actor Actor, userID int) (int, error) {
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if u == nil {
+		return 0, ErrNotFound
+	}
+	n, err := s.endSessions(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	s.audit.Record(ctx, actor, model.AuditSessionsRevoked, "user", userID, map[string]interface{}{"sessions": n})
+	return n, nil
+}
+
+// endSessions отзывает все сессии пользователя без записи в аудит — для
+// случаев, когда событие записывает вызывающий (сброс пароля и т.п.).
+func (s *TokenService) endSessions(ctx context.Context, userID int) (int, error) {
+	return s.sessions.RevokeAllForUser(ctx, userID, time.Now().UTC())
+}
+
+// endOtherSessions отзывает все сессии пользователя, кроме текущей, — после
+// смены пароля остальные устройства должны войти заново.
+func (s *TokenService) endOtherSessions(ctx context.Context, userID int, keepSessionID string) (int, error) {
+	return s.sessions.RevokeOthersForUser(ctx, userID, keepSessionID, time.Now().UTC())
+}
+
+// SessionActive сообщает middleware, что сессия access-токена не отозвана.
+func (s *TokenService) SessionActive(ctx context.Context, sessionID string) (bool, error) {
+	return s.sessions.Active(ctx, sessionID, time.Now().UTC())
+}
+
+func (s *TokenService) pair(u *model.User, sid, secret string, now time.Time) (*TokenPair, error) {
+	exp := now.Add(s.accessTTL)
+	claims := jwt.MapClaims{
+		"sub":  u.ID,
+		"role": u.Role,
+		"sid":  sid,
+		"iat":  now.Unix(),
+		"exp":  exp.Unix(),
+	}
+	access, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{AccessToken: access, ExpiresAt: exp, RefreshToken: sid + "." + secret}, nil
+}
+
+// randomToken возвращает n случайных байт в base64url.
+func randomToken(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err) // crypto/rand не должен отказывать
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// hashToken — в базе хранится только SHA-256 от секрета токена.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
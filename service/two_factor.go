@@ -0,0 +1,233 @@
+package service
+
+import (
+	"cinema-system/model"
+	"cinema-system/totp"
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"strings"
+	"time"
+)
+
+const (
+	totpIssuer        = "Cinema System"
+	totpSkew          = 1 // допуск ±1 интервал (30 с) на расхождение часов
+	recoveryCodeCount = 10
+	login2FATTL       = 5 * time.Minute
+)
+
+// TOTPEnrollment — секрет для приложения-аутентификатора: вводится вручную
+// или сканируется из otpauth://-ссылки как QR-код.
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URL    string `json:"otpauthUrl"`
+}
+
+// LoginChallenge — пароль принят, для входа нужен код второго фактора.
+type LoginChallenge struct {
+	Token     string    `json:"mfaToken"`
+	ExpiresAt time.Time `json:"mfaExpiresAt"`
+	// Enrollment заполнен, если роль требует второй фактор, а он ещё не
+	// подключён: код из приложения заодно подтвердит подключение.
+	Enrollment *TOTPEnrollment `json:"enrollment,omitempty"`
+}
+
+// StartSecondFactor вызывается после проверки пароля. Если второй фактор
+// включён у пользователя или обязателен для его роли, возвращает одноразовый
+// токен для второго шага входа; иначе nil — токены можно выдавать сразу.
+func (s *UserService) StartSecondFactor(ctx context.Context, u *model.User) (*LoginChallenge, error) {
+	if !u.TOTPEnabled && !s.roles.Requires2FA(u.Role) {
+		return nil, nil
+	}
+	ch := &LoginChallenge{}
+	if !u.TOTPEnabled {
+		enr, err := s.beginTOTP(ctx, u)
+		if err != nil {
+			return nil, err
+		}
+		ch.Enrollment = enr
+	}
+	now := time.Now().UTC()
+	ch.Token = randomToken(32)
+	ch.ExpiresAt = now.Add(login2FATTL)
+	err := s.tokens.Create(ctx, &model.UserToken{
+		Hash:      hashToken(ch.Token),
+		UserID:    u.ID,
+		Purpose:   model.TokenLogin2FA,
+		CreatedAt: now,
+		ExpiresAt: ch.ExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// ChallengeUser гасит токен второго шага входа и возвращает пользователя.
+// Токен срабатывает один раз: после неверного кода нужно войти заново.
+func (s *UserService) ChallengeUser(ctx context.Context, mfaToken string) (*model.User, error) {
+	t, err := s.tokens.Consume(ctx, hashToken(mfaToken), model.TokenLogin2FA, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, invalidf("login attempt expired; log in again")
+	}
+	u, err := s.repo.GetByID(ctx, t.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil || u.Disabled {
+		return nil, invalidf("login attempt expired; log in again")
+	}
+	return u, nil
+}
+
+// CompleteSecondFactor проверяет код второго шага входа. Если второй фактор
+// подключался при этом входе, включает его и возвращает коды восстановления.
+func (s *UserService) CompleteSecondFactor(ctx context.Context, u *model.User, code string) ([]string, error) {
+	if u.TOTPEnabled {
+		return nil, s.checkSecondFactor(ctx, u, code)
+	}
+	if u.TOTPPendingSecret == "" {
+		return nil, invalidf("two-factor authentication is not set up")
+	}
+	return s.enableTOTP(ctx, u, code)
+}
+
+// BeginTOTP выдаёт новый секрет для подключения второго фактора. Включится
+// он после подтверждения кодом (ConfirmTOTP).
+func (s *UserService) BeginTOTP(ctx context.Context, userID int) (*TOTPEnrollment, error) {
+	u, err := s.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.TOTPEnabled {
+		return nil, &ConflictError{Msg: "two-factor authentication is already enabled"}
+	}
+	return s.beginTOTP(ctx, u)
+}
+
+// ConfirmTOTP включает второй фактор по первому коду из приложения и
+// возвращает коды восстановления. Они показываются один раз.
+func (s *UserService) ConfirmTOTP(ctx context.Context, userID int, code string) ([]string, error) {
+	u, err := s.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.TOTPEnabled {
+		return nil, &ConflictError{Msg: "two-factor authentication is already enabled"}
+	}
+	if u.TOTPPendingSecret == "" {
+		return nil, invalidf("start enrolment first")
+	}
+	return s.enableTOTP(ctx, u, code)
+}
+
+// DisableTOTP выключает второй фактор, если он не обязателен для роли.
+func (s *UserService) DisableTOTP(ctx context.Context, userID int, code string) error {
+	u, err := s.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !u.TOTPEnabled {
+		return &ConflictError{Msg: "two-factor authentication is not enabled"}
+	}
+	if s.roles.Requires2FA(u.Role) {
+		return &ConflictError{Msg: "two-factor authentication is mandatory for role " + u.Role}
+	}
+	if err := s.checkSecondFactor(ctx, u, code); err != nil {
+		return err
+	}
+	if err := s.repo.DisableTOTP(ctx, u.ID); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, Actor{UserID: u.ID, Role: u.Role}, model.Audit2FADisabled, "user", u.ID, nil)
+	return nil
+}
+
+// RegenerateRecoveryCodes выдаёт новые коды восстановления взамен старых.
+func (s *UserService) RegenerateRecoveryCodes(ctx context.Context, userID int, code string) ([]string, error) {
+	u, err := s.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !u.TOTPEnabled {
+		return nil, &ConflictError{Msg: "two-factor authentication is not enabled"}
+	}
+	if err := s.checkSecondFactor(ctx, u, code); err != nil {
+		return nil, err
+	}
+	codes, hashes := newRecoveryCodes()
+	if err := s.repo.SetRecoveryCodes(ctx, u.ID, hashes); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, Actor{UserID: u.ID, Role: u.Role}, model.AuditRecoveryCodesNew, "user", u.ID, nil)
+	return codes, nil
+}
+
+func (s *UserService) beginTOTP(ctx context.Context, u *model.User) (*TOTPEnrollment, error) {
+	secret := totp.NewSecret()
+	if err := s.repo.SetTOTPPending(ctx, u.ID, secret); err != nil {
+		return nil, err
+	}
+	return &TOTPEnrollment{Secret: secret, URL: totp.URL(totpIssuer, u.Email, secret)}, nil
+}
+
+func (s *UserService) enableTOTP(ctx context.Context, u *model.User, code string) ([]string, error) {
+	step, ok := totp.Match(u.TOTPPendingSecret, code, time.Now(), totpSkew, 0)
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+	codes, hashes := newRecoveryCodes()
+	if err := s.repo.EnableTOTP(ctx, u.ID, u.TOTPPendingSecret, step, hashes); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, Actor{UserID: u.ID, Role: u.Role}, model.Audit2FAEnabled, "user", u.ID, nil)
+	return codes, nil
+}
+
+// checkSecondFactor принимает код из приложения или код восстановления.
+// Каждый код срабатывает один раз.
+func (s *UserService) checkSecondFactor(ctx context.Context, u *model.User, code string) error {
+	if step, ok := totp.Match(u.TOTPSecret, code, time.Now(), totpSkew, u.TOTPLastStep); ok {
+		advanced, err := s.repo.AdvanceTOTPStep(ctx, u.ID, step)
+		if err != nil {
+			return err
+		}
+		if advanced {
+			return nil
+		}
+		return ErrInvalidCode
+	}
+	used, err := s.repo.UseRecoveryCode(ctx, u.ID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidCode
+	}
+	s.audit.Record(ctx, Actor{UserID: u.ID, Role: u.Role}, model.AuditRecoveryCodeUsed, "user", u.ID, nil)
+	return nil
+}
+
+// newRecoveryCodes возвращает коды восстановления вида "abcd-efgh" и их хеши.
+func newRecoveryCodes() (codes, hashes []string) {
+	enc := base32.StdEncoding.WithPadding(base32.NoPadding)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			panic(err) // crypto/rand не должен отказывать
+		}
+		c := strings.ToLower(enc.EncodeToString(b))
+		c = c[:4] + "-" + c[4:]
+		codes = append(codes, c)
+		hashes = append(hashes, hashToken(normalizeRecoveryCode(c)))
+	}
+	return codes, hashes
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"cinema-system/model"
+	"cinema-system/repository"
+	"context"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Размер страницы списка пользователей.
+const (
+	defaultUserPageSize = 20
+	maxUserPageSize     = 100
+)
+
+// UserPage — страница списка пользователей.
+type UserPage struct {
+	Items    []*model.User `json:"items"`
+	Total    int64         `json:"total"`
+	Page     int           `json:"page"`
+	PageSize int           `json:"pageSize"`
+}
+
+// UserQuery — параметры списка пользователей.
+type UserQuery struct {
+	Search   string
+	Role     string
+	Disabled *bool
+	Page     int // с 1
+	PageSize int
+}
+
+// UserAdminService — управление учётными записями администратором: список,
+// смена роли, отключение и сброс пароля. Все изменения пишутся в аудит.
+type UserAdminService struct {
+	users  *repository.UserRepo
+	roles  *RoleService
+	logins *TokenService
+	audit  *AuditService
+}
+
+func NewUserAdminService(users *repository.UserRepo, roles *RoleService, logins *TokenService, audit *AuditService) *UserAdminService {
+	return &UserAdminService{users: users, roles: roles, logins: logins, audit: audit}
+}
+
+// List возвращает страницу пользователей по id с поиском по email и имени.
+func (s *UserAdminService) List(ctx context.Context, q UserQuery) (*UserPage, error) {
+	if q.Page <= 0 {
+		q.Page = 1
+	}
+	if q.PageSize <= 0 {
+		q.PageSize = defaultUserPageSize
+	}
+	if q.PageSize > maxUserPageSize {
+		q.PageSize = maxUserPageSize
+	}
+	items, total, err := s.users.Find(ctx, repository.UserFilter{
+		Query:    strings.TrimSpace(q.Search),
+		Role:     q.Role,
+		Disabled: q.Disabled,
+		Skip:     (q.Page - 1) * q.PageSize,
+		Limit:    q.PageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []*model.User{}
+	}
+	return &UserPage{Items: items, Total: total, Page: q.Page, PageSize: q.PageSize}, nil
+}
+
+// Get возвращает пользователя по id.
+func (s *UserAdminService) Get(ctx context.Context, id int) (*model.User, error) {
+	u, err := s.users.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, ErrNotFound
+	}
+	return u, nil
+}
+
+// SetRole назначает пользователю роль. Свою роль администратор не меняет,
+// чтобы случайно не остаться без доступа. Сессии пользователя завершаются:
+// роль зашита в уже выданные токены.
+func (s *UserAdminService) SetRole(ctx context.Context, actor Actor, id int, role string) (*model.User, error) {
+	if !s.roles.Exists(role) {
+		return nil, invalidf("unknown role %q", role)
+	}
+	if id == actor.UserID {
+		return nil, invalidf("you cannot change your own role")
+	}
+	u, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if u.Role == role {
+		return u, nil
+	}
+	if _, err := s.users.SetRole(ctx, id, role); err != nil {
+		return nil, err
+	}
+	if _, err := s.logins.endSessions(ctx, id); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, actor, model.AuditUserRoleChanged, "user", id, map[string]interface{}{"from": u.Role, "to": role})
+	u.Role = role
+	return u, nil
+}
+
+// SetActive включает или отключает учётную запись. Отключённый пользователь
+// не может войти, а его текущие сессии завершаются сразу.
+func (s *UserAdminService) SetActive(ctx context.Context, actor Actor, id int, active bool) (*model.User, error) {
+	if id == actor.UserID && !active {
+		return nil, invalidf("you cannot deactivate your own account")
+	}
+	u, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if u.Disabled == !active {
+		return u, nil
+	}
+	if _, err := s.users.SetDisabled(ctx, id, !active); err != nil {
+		return nil, err
+	}
+	action := model.AuditUserActivated
+	if !active {
+		action = model.AuditUserDeactivated
+		if _, err := s.logins.endSessions(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	s.audit.Record(ctx, actor, action, "user", id, nil)
+	u.Disabled = !active
+	return u, nil
+}
+
+// SetPassword задаёт пользователю временный пароль (например, кассиру, который
+// его забыл) и завершает все его сессии. При входе пароль придётся сменить.
+func (s *UserAdminService) SetPassword(ctx context.Context, actor Actor, id int, password string) error {
+	if len(password) < MinPasswordLength {
+		return invalidf("password must be at least %d characters", MinPasswordLength)
+	}
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := s.users.SetPasswordHash(ctx, id, string(hash), true); err != nil {
+		return err
+	}
+	if _, err := s.logins.endSessions(ctx, id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, actor, model.AuditPasswordSetByAdmin, "user", id, nil)
+	return nil
+}
+
+// ResetTOTP выключает пользователю второй фактор (например, при потере
+// телефона без кодов восстановления). Если роль требует второй фактор, он
+// подключится заново при следующем входе.
+func (s *UserAdminService) ResetTOTP(ctx context.Context, actor Actor, id int) error {
+	u, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !u.TOTPEnabled && u.TOTPPendingSecret == "" {
+		return nil
+	}
+	if err := s.users.DisableTOTP(ctx, id); err != nil {
+		return err
+	}
+	if _, err := s.logins.endSessions(ctx, id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, actor, model.Audit2FAReset, "user", id, nil)
+	return nil
+}
@@ -14,19 +14,40 @@ import (
 const (
 	RoleCustomer = "customer"
 	RoleCashier  = "cashier"
+	RoleUsher    = "usher" // контролёр на входе в зал
 	RoleAdmin    = "admin"
 )
 
+// ErrAccountDisabled — учётная запись отключена администратором.
+var ErrAccountDisabled = errors.New("account is disabled")
+
+// ErrInvalidCredentials — неверный email или пароль.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// ErrInvalidCode — неверный или уже использованный код второго фактора.
+var ErrInvalidCode = errors.New("invalid verification code")
+
+// ErrPasswordChangeRequired — пароль верный, но его нужно сменить до входа.
+var ErrPasswordChangeRequired = errors.New("password change required")
+
+// ErrSecondFactorRequired — для смены временного пароля нужен код второго фактора.
+var ErrSecondFactorRequired = errors.New("two-factor code required")
+
 // UserService инкапсулирует бизнес-логику работы с пользователями.
 type UserService struct {
-	repo *repository.UserRepo
+	repo   *repository.UserRepo
+	tokens *repository.UserTokenRepo
+	roles  *RoleService
+	logins *TokenService
+	audit  *AuditService
 }
 
-func NewUserService(repo *repository.UserRepo) *UserService {
-	return &UserService{repo: repo}
+func NewUserService(repo *repository.UserRepo, tokens *repository.UserTokenRepo, roles *RoleService, logins *TokenService, audit *AuditService) *UserService {
+	return &UserService{repo: repo, tokens: tokens, roles: roles, logins: logins, audit: audit}
 }
 
 // EnsureUserWithRole создаёт пользователя с заданной ролью, если такого email ещё нет.
+// Пароль из конфигурации считается временным: при первом входе его нужно сменить.
 func (s *UserService) EnsureUserWithRole(ctx context.Context, email, password, name, role string) error {
 	if email == "" || password == "" {
 		return nil
@@ -46,19 +67,15 @@ func (s *UserService) EnsureUserWithRole(ctx context.Context, email, password, n
 	}
 
 	_, err = s.repo.Create(ctx, &model.User{
-		Email:        email,
-		PasswordHash: string(hash),
-		Name:         name,
-		Role:         role,
+		Email:              email,
+		PasswordHash:       string(hash),
+		Name:               name,
+		Role:               role,
+		MustChangePassword: true,
 	})
 	return err
 }
 
-// EnsureDefaultAdmin создаёт админа, если его ещё нет.
-func (s *UserService) EnsureDefaultAdmin(ctx context.Context, email, password, name string) error {
-	return s.EnsureUserWithRole(ctx, email, password, name, RoleAdmin)
-}
-
 // RegisterCustomer регистрирует обычного пользователя‑покупателя.
 func (s *UserService) RegisterCustomer(ctx context.Context, name, email, password string) (*model.User, error) {
 	existing, err := s.repo.GetByEmail(ctx, email)
@@ -84,16 +101,86 @@ func (s *UserService) RegisterCustomer(ctx context.Context, name, email, passwor
 
 // Authenticate проверяет email+пароль и возвращает пользователя.
 func (s *UserService) Authenticate(ctx context.Context, email, password string) (*model.User, error) {
+	u, err := s.checkPassword(ctx, email, password)
+	if err != nil {
+		return nil, err
+	}
+	if u.MustChangePassword {
+		return nil, ErrPasswordChangeRequired
+	}
+	return u, nil
+}
+
+// ChangePassword меняет временный пароль (MustChangePassword) по email и
+// текущему паролю — без этого такие пользователи не могут войти. Если у
+// пользователя включён второй фактор, нужен и его код: иначе знающий только
+// временный пароль мог бы сменить его за владельца. Остальные меняют пароль
+// через PATCH /api/me. Все прежние сессии пользователя завершаются.
+func (s *UserService) ChangePassword(ctx context.Context, email, password, newPassword, code string) (*model.User, error) {
+	u, err := s.checkPassword(ctx, email, password)
+	if err != nil {
+		return nil, err
+	}
+	if !u.MustChangePassword {
+		return nil, &ConflictError{Msg: "password is not temporary; change it in the profile"}
+	}
+	if len(newPassword) < MinPasswordLength {
+		return nil, invalidf("password must be at least %d characters", MinPasswordLength)
+	}
+	if newPassword == password {
+		return nil, invalidf("new password must differ from the current one")
+	}
+	if u.TOTPEnabled {
+		if code == "" {
+			return nil, ErrSecondFactorRequired
+		}
+		if err := s.checkSecondFactor(ctx, u, code); err != nil {
+			return nil, err
+		}
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetPasswordHash(ctx, u.ID, string(hash), false); err != nil {
+		return nil, err
+	}
+	n, err := s.logins.endSessions(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, Actor{UserID: u.ID, Role: u.Role}, model.AuditPasswordChanged, "user", u.ID, map[string]interface{}{"sessionsRevoked": n})
+	u.PasswordHash = string(hash)
+	u.MustChangePassword = false
+	return u, nil
+}
+
+// GetByID возвращает пользователя по id или ErrNotFound.
+func (s *UserService) GetByID(ctx context.Context, id int) (*model.User, error) {
+	u, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, ErrNotFound
+	}
+	return u, nil
+}
+
+// checkPassword находит активного пользователя по email и сверяет пароль.
+func (s *UserService) checkPassword(ctx context.Context, email, password string) (*model.User, error) {
 	u, err := s.repo.GetByEmail(ctx, email)
 	if err != nil {
 		return nil, err
 	}
 	if u == nil {
-		return nil, errors.New("invalid credentials")
+		return nil, ErrInvalidCredentials
 	}
 	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)); err != nil {
-		return nil, errors.New("invalid credentials")
+		return nil, ErrInvalidCredentials
+	}
+	if u.Disabled {
+		return nil, ErrAccountDisabled
 	}
 	return u, nil
 }
-
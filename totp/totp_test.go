@@ -0,0 +1,78 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret — ключ "12345678901234567890" из приложения B RFC 6238 в base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodeRFC6238Vectors(t *testing.T) {
+	// В RFC коды из 8 цифр; наши 6 цифр — их последние шесть.
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code(%d): %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("Code(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestCodeInvalidSecret(t *testing.T) {
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Fatal("Code accepted an invalid secret")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	step := Step(now)
+	code := func(s int64) string {
+		c, err := Code(rfcSecret, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	tests := []struct {
+		name     string
+		code     string
+		skew     int
+		after    int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", code(step), 1, 0, step, true},
+		{"previous step within skew", code(step - 1), 1, 0, step - 1, true},
+		{"next step within skew", code(step + 1), 1, 0, step + 1, true},
+		{"previous step without skew", code(step - 1), 0, 0, 0, false},
+		{"two steps back outside skew", code(step - 2), 1, 0, 0, false},
+		{"spaces are ignored", code(step)[:3] + " " + code(step)[3:], 1, 0, step, true},
+		{"wrong length", code(step)[:5], 1, 0, 0, false},
+		{"replay of the last used step", code(step), 1, step, 0, false},
+		{"older step after use", code(step - 1), 1, step - 1, 0, false},
+		{"newer step after use", code(step + 1), 1, step, step + 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Match(rfcSecret, tt.code, now, tt.skew, tt.after)
+			if ok != tt.wantOK || got != tt.wantStep {
+				t.Errorf("Match = (%d, %v), want (%d, %v)", got, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}